@@ -7,10 +7,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"runtime"
+	"path/filepath"
+	"strings"
 
 	"fyne.io/fyne/v2/app"
 
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/cli"
 	"github.com/dcvix/dcvix-stats/internal/globals"
 	"github.com/dcvix/dcvix-stats/internal/gui"
 	"github.com/dcvix/dcvix-stats/internal/logger"
@@ -24,11 +27,23 @@ var WinHeigh = 384
 
 func main() {
 
+	// Subcommands run without opening any window
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		if err := cli.Run(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	showVersion := flag.Bool("version", false, "Show version information")
-	flag.BoolVar(&globals.Verbose, "verbose", false, "Enable verbose logging")
-	flag.IntVar(&globals.LogEntriesQty, "entries", 120, "How many last entries/minutes to evaluate")
-	flag.StringVar(&globals.LogFile, "logfile", getDefaultLogPath(), "Path to the DCV server log file")
-	flag.IntVar(&globals.RefreshInterval, "refresh", 30, "Auto-refresh interval in seconds")
+	cli.RegisterLogFlags(flag.CommandLine)
+	flag.IntVar(&globals.RefreshInterval, "refresh", 30, "Auto-refresh polling interval in seconds, for logs on filesystems without change notifications")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n       %s <command> [flags]\n\nCommands: %s\n\nFlags:\n",
+			os.Args[0], os.Args[0], strings.Join(cli.Commands(), ", "))
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	if *showVersion {
@@ -36,18 +51,33 @@ func main() {
 		os.Exit(0)
 	}
 
+	// setup main window.
+	a := app.NewWithID(globals.AppID)
+
+	// Settings changed in the Preferences dialog apply unless given as flags
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	gui.LoadSettings(a.Preferences(), setFlags)
+
 	logger.LogVerbosef("Starting log parser for file: %s\n", globals.LogFile)
 	logger.LogVerbosef("Refreshing every %v seconds...\n", globals.RefreshInterval)
 
-	// setup main window.
-	a := app.NewWithID(globals.AppID)
-	w := gui.NewMainWindow(a)
-	w.ShowAndRun()
-}
+	catalogPath := filepath.Join(a.Storage().RootURI().Path(), catalog.FileName)
+	cat, err := catalog.Load(catalogPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error, could not load metric catalog: %v\n", err)
+		os.Exit(1)
+	}
+	logger.LogVerbosef("Metric catalog loaded, user catalog: %s\n", catalogPath)
 
-func getDefaultLogPath() string {
-	if runtime.GOOS == "windows" {
-		return `C:\ProgramData\NICE\dcv\log\server.log`
+	w, err := gui.NewMainWindow(a, cat, gui.Settings{
+		LogFile:         globals.LogFile,
+		LogEntriesQty:   globals.LogEntriesQty,
+		RefreshInterval: globals.RefreshInterval,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error, could not open log source %s: %v\n", globals.LogFile, err)
+		os.Exit(1)
 	}
-	return "/var/log/dcv/server.log"
+	w.ShowAndRun()
 }
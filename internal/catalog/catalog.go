@@ -0,0 +1,251 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package catalog
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// FileName is the name of the user catalog, looked up in the preferences directory
+const FileName = "catalog.toml"
+
+//go:embed default.toml
+var defaultCatalog string
+
+// Metric is a stats metric written by DCV to the server log
+type Metric struct {
+	Name        string `toml:"name"`
+	Unit        string `toml:"unit"`
+	Description string `toml:"description"`
+}
+
+// Graph is a chart listed in the Show menu
+type Graph struct {
+	// Name identifies the graph, it is also the key of its Show preference
+	Name  string `toml:"name"`
+	Title string `toml:"title"`
+	// Metrics are catalog metric names, optionally with an aggregate suffix like _avg
+	Metrics []string `toml:"metrics"`
+	// Unit overrides the unit of the graph metrics
+	Unit    string `toml:"unit"`
+	Enabled bool   `toml:"enabled"`
+	// Type is the chart type, one of GraphTypes, line if empty
+	Type string `toml:"type,omitempty"`
+	// YMin and YMax fix the bounds of the y axis, automatic if not set
+	YMin *float64 `toml:"y_min,omitempty"`
+	YMax *float64 `toml:"y_max,omitempty"`
+}
+
+// GraphTypes are the chart types of a graph
+var GraphTypes = []string{"line", "step", "bar"}
+
+// MaxAxisBound is the largest absolute value of the y axis bounds of a graph, larger
+// values can't be divided in axis ticks
+const MaxAxisBound = 1e15
+
+type Catalog struct {
+	Metrics []Metric `toml:"metric"`
+	Graphs  []Graph  `toml:"graph"`
+}
+
+// Default returns the catalog shipped with the application
+func Default() (*Catalog, error) {
+	var c Catalog
+	if _, err := toml.Decode(defaultCatalog, &c); err != nil {
+		return nil, fmt.Errorf("default catalog: %w", err)
+	}
+	return &c, c.Validate()
+}
+
+// Load returns the default catalog merged with the user catalog at path, if it exists.
+// User metrics and graphs replace the default ones with the same name.
+func Load(path string) (*Catalog, error) {
+	c, err := Default()
+	if err != nil {
+		return nil, err
+	}
+
+	var user Catalog
+	if _, err := toml.DecodeFile(path, &user); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("catalog %s: %w", path, err)
+	}
+
+	for _, m := range user.Metrics {
+		c.setMetric(m)
+	}
+	for _, g := range user.Graphs {
+		c.setGraph(g)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("catalog %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate checks that names are set and unique and that graphs only use catalog metrics
+func (c *Catalog) Validate() error {
+	metrics := make(map[string]bool)
+	for _, m := range c.Metrics {
+		if m.Name == "" {
+			return errors.New("metric without name")
+		}
+		if strings.ContainsAny(m.Name, " \t:") {
+			return fmt.Errorf("invalid metric name %q", m.Name)
+		}
+		if metrics[m.Name] {
+			return fmt.Errorf("duplicated metric %q", m.Name)
+		}
+		metrics[m.Name] = true
+	}
+
+	graphs := make(map[string]bool)
+	for _, g := range c.Graphs {
+		if g.Name == "" {
+			return errors.New("graph without name")
+		}
+		if graphs[g.Name] {
+			return fmt.Errorf("duplicated graph %q", g.Name)
+		}
+		graphs[g.Name] = true
+
+		if err := g.validateChart(); err != nil {
+			return err
+		}
+		for _, name := range g.Metrics {
+			if metric, _ := logparser.SplitSeriesName(name); !metrics[metric] {
+				return fmt.Errorf("graph %q: unknown metric %q", g.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateChart checks the metrics list, the type and the y axis bounds of a graph
+func (g Graph) validateChart() error {
+	if len(g.Metrics) == 0 {
+		return fmt.Errorf("graph %q has no metrics", g.Name)
+	}
+	if g.Type != "" && !slices.Contains(GraphTypes, g.Type) {
+		return fmt.Errorf("graph %q: unknown type %q, expected one of %s", g.Name, g.Type, strings.Join(GraphTypes, ", "))
+	}
+	for _, bound := range []*float64{g.YMin, g.YMax} {
+		if bound != nil && !(math.Abs(*bound) <= MaxAxisBound) {
+			return fmt.Errorf("graph %q: the y axis bounds must be between -%g and %g", g.Name, MaxAxisBound, MaxAxisBound)
+		}
+	}
+	if g.YMin != nil && g.YMax != nil && *g.YMin >= *g.YMax {
+		return fmt.Errorf("graph %q: y_min must be lower than y_max", g.Name)
+	}
+	return nil
+}
+
+// graphList is the TOML document of EncodeGraphs
+type graphList struct {
+	Graphs []Graph `toml:"graph"`
+}
+
+// EncodeGraphs returns graphs as a TOML document, e.g. to save the graphs defined by the user
+func EncodeGraphs(graphs []Graph) (string, error) {
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(graphList{Graphs: graphs}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// DecodeGraphs parses the graphs encoded by EncodeGraphs. Their metrics are not checked
+// against the catalog, they can be metrics discovered in the log.
+func DecodeGraphs(data string) ([]Graph, error) {
+	var list graphList
+	if _, err := toml.Decode(data, &list); err != nil {
+		return nil, err
+	}
+	for _, g := range list.Graphs {
+		if g.Name == "" {
+			return nil, errors.New("graph without name")
+		}
+		if err := g.validateChart(); err != nil {
+			return nil, err
+		}
+	}
+	return list.Graphs, nil
+}
+
+// MetricNames returns the names of all the catalog metrics
+func (c *Catalog) MetricNames() []string {
+	names := make([]string, len(c.Metrics))
+	for i, m := range c.Metrics {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// Metric returns the catalog metric with the given name
+func (c *Catalog) Metric(name string) (Metric, bool) {
+	for _, m := range c.Metrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+// GraphUnit returns the unit of a graph: its own or the one shared by all of its metrics
+func (c *Catalog) GraphUnit(g Graph) string {
+	if g.Unit != "" {
+		return g.Unit
+	}
+	unit := ""
+	for i, name := range g.Metrics {
+		metricName, _ := logparser.SplitSeriesName(name)
+		m, _ := c.Metric(metricName)
+		if i > 0 && m.Unit != unit {
+			return ""
+		}
+		unit = m.Unit
+	}
+	return unit
+}
+
+// Label returns the title of a graph, its name if no title is set
+func (g Graph) Label() string {
+	if g.Title != "" {
+		return g.Title
+	}
+	return g.Name
+}
+
+func (c *Catalog) setMetric(m Metric) {
+	for i := range c.Metrics {
+		if c.Metrics[i].Name == m.Name {
+			c.Metrics[i] = m
+			return
+		}
+	}
+	c.Metrics = append(c.Metrics, m)
+}
+
+func (c *Catalog) setGraph(g Graph) {
+	for i := range c.Graphs {
+		if c.Graphs[i].Name == g.Name {
+			c.Graphs[i] = g
+			return
+		}
+	}
+	c.Graphs = append(c.Graphs, g)
+}
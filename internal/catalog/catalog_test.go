@@ -0,0 +1,52 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package catalog
+
+import (
+	"testing"
+)
+
+func TestEncodeDecodeGraphs(t *testing.T) {
+	yMin, yMax := 0.0, 50.0
+	graphs := []Graph{{
+		Name:    "User.Mix",
+		Title:   "Mix",
+		Metrics: []string{"recv_lost_dgrams", "quic_rtt_nanos_avg"},
+		Enabled: true,
+		Type:    "bar",
+		YMin:    &yMin,
+		YMax:    &yMax,
+	}}
+	data, err := EncodeGraphs(graphs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeGraphs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("got %d graphs, want 1", len(decoded))
+	}
+	g := decoded[0]
+	if g.Name != "User.Mix" || g.Type != "bar" || len(g.Metrics) != 2 || *g.YMin != yMin || *g.YMax != yMax {
+		t.Errorf("got %+v, want %+v", g, graphs[0])
+	}
+}
+
+func TestDecodeGraphsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no metrics":       `[[graph]]` + "\n" + `name = "g"`,
+		"unknown type":     `[[graph]]` + "\n" + `name = "g"` + "\n" + `metrics = ["m"]` + "\n" + `type = "pie"`,
+		"min above max":    `[[graph]]` + "\n" + `name = "g"` + "\n" + `metrics = ["m"]` + "\n" + `y_min = 10.0` + "\n" + `y_max = 5.0`,
+		"large min":        `[[graph]]` + "\n" + `name = "g"` + "\n" + `metrics = ["m"]` + "\n" + `y_min = 1e17`,
+		"infinite max":     `[[graph]]` + "\n" + `name = "g"` + "\n" + `metrics = ["m"]` + "\n" + `y_max = inf`,
+		"not a number min": `[[graph]]` + "\n" + `name = "g"` + "\n" + `metrics = ["m"]` + "\n" + `y_min = nan`,
+	}
+	for name, data := range tests {
+		if _, err := DecodeGraphs(data); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2025 Diego Cortassa
+// SPDX-License-Identifier: MIT
+
+package charts
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// timeSteps are the intervals between the time axis ticks, the smallest one giving at
+// most the requested number of ticks is used
+var timeSteps = []time.Duration{
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 2 * 24 * time.Hour, 7 * 24 * time.Hour, 14 * 24 * time.Hour, 28 * 24 * time.Hour,
+}
+
+// FormatValue formats axis values, keeping decimals only for small fractional values,
+// followed by unit if not empty
+func FormatValue(f float64, unit string) string {
+	value := fmt.Sprintf("%.2f", f)
+	if math.Abs(f) >= 10 || f == math.Trunc(f) {
+		value = fmt.Sprintf("%.0f", f)
+	}
+	if unit == "" {
+		return value
+	}
+	return value + " " + unit
+}
+
+// maxValueTicks bounds the number of ticks returned by ValueTicks
+const maxValueTicks = 100
+
+// ValueTicks returns about count evenly spaced round values covering lo and hi.
+// It returns just lo and hi when they can't be divided in round steps, e.g. when they are
+// not finite or so large that the step is lost in their rounding.
+func ValueTicks(lo, hi float64, count int) []float64 {
+	if hi <= lo {
+		// lo + 1 is lo for very large values
+		hi = max(lo+1, math.Nextafter(lo, math.Inf(1)))
+	}
+
+	step := niceNumber((hi - lo) / float64(max(count, 1)))
+	if !isFinite(lo) || !isFinite(hi) || !isFinite(step) || step <= 0 || (hi-lo)/step > maxValueTicks ||
+		lo+step == lo || hi+step == hi {
+		return []float64{lo, hi}
+	}
+
+	first := math.Floor(lo / step)
+	var ticks []float64
+	for k := first; len(ticks) < maxValueTicks; k++ {
+		ticks = append(ticks, k*step)
+		if k*step >= hi-step*1e-9 {
+			break
+		}
+	}
+	if len(ticks) < 2 || !isFinite(ticks[len(ticks)-1]) {
+		return []float64{lo, hi}
+	}
+	return ticks
+}
+
+// isFinite reports whether f is neither infinite nor NaN
+func isFinite(f float64) bool {
+	return !math.IsInf(f, 0) && !math.IsNaN(f)
+}
+
+// niceNumber rounds x up to 1, 2, 2.5 or 5 times a power of ten
+func niceNumber(x float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(x)))
+	for _, n := range []float64{1, 2, 2.5, 5} {
+		if x <= n*exp {
+			return n * exp
+		}
+	}
+	return 10 * exp
+}
+
+// TimeTicks returns at most count round times between from and to. With origin set the
+// ticks are round durations after it, otherwise round times of the local day.
+func TimeTicks(from, to, origin time.Time, count int) []time.Time {
+	if !to.After(from) || count < 1 {
+		return nil
+	}
+	span := to.Sub(from)
+	step := timeSteps[len(timeSteps)-1]
+	for _, s := range timeSteps {
+		if span/s <= time.Duration(count) {
+			step = s
+			break
+		}
+	}
+
+	base := origin
+	if base.IsZero() {
+		local := from.Local()
+		base = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	}
+	first := base.Add(from.Sub(base) / step * step)
+	if first.Before(from) {
+		first = first.Add(step)
+	}
+
+	var ticks []time.Time
+	for t := first; !t.After(to); t = t.Add(step) {
+		ticks = append(ticks, t)
+	}
+	return ticks
+}
+
+// FormatTime formats a time axis label for an axis showing from to to, as the time elapsed
+// since origin if it is set
+func FormatTime(t, from, to, origin time.Time) string {
+	if !origin.IsZero() {
+		return formatElapsed(t.Sub(origin))
+	}
+	return t.Local().Format(timeLayout(from, to))
+}
+
+// formatElapsed formats d as +h:mm, with the days when longer than a day
+func formatElapsed(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d/time.Hour) % 24
+	minutes := int(d/time.Minute) % 60
+	if days > 0 {
+		return fmt.Sprintf("%s%dd %d:%02d", sign, days, hours, minutes)
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, hours, minutes)
+}
+
+// timeLayout returns the layout of the time labels of an axis from first to last
+func timeLayout(first, last time.Time) string {
+	first, last = first.Local(), last.Local()
+	span := last.Sub(first)
+	switch {
+	case span > 7*24*time.Hour:
+		return "2006-01-02"
+	case first.YearDay() != last.YearDay() || first.Year() != last.Year():
+		return "Jan 2 15:04"
+	case span < time.Hour:
+		return "15:04:05"
+	}
+	return "15:04"
+}
@@ -0,0 +1,63 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package charts
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValueTicks(t *testing.T) {
+	tests := []struct {
+		lo, hi float64
+		want   []float64
+	}{
+		{0, 37, []float64{0, 10, 20, 30, 40}},
+		{-5, 5, []float64{-6, -4, -2, 0, 2, 4, 6}},
+		{0, 0, []float64{0, 0.2, 0.4, 0.6000000000000001, 0.8, 1}},
+		{1e17, 2e17, []float64{1e17, 1.2e17, 1.4e17, 1.6e17, 1.8e17, 2e17}},
+	}
+	for _, test := range tests {
+		if got := valueTicks(t, test.lo, test.hi); !slices.Equal(got, test.want) {
+			t.Errorf("ValueTicks(%v, %v) = %v, want %v", test.lo, test.hi, got, test.want)
+		}
+	}
+}
+
+// The bounds that can't be divided in round steps are returned as they are
+func TestValueTicksDegenerate(t *testing.T) {
+	tests := []struct{ lo, hi float64 }{
+		{0, math.Inf(1)},
+		{math.Inf(-1), 0},
+		{math.NaN(), 1},
+		{1e17, 1e17 + 1},
+		{1e300, math.MaxFloat64},
+		{-math.MaxFloat64, math.MaxFloat64},
+	}
+	for _, test := range tests {
+		got := valueTicks(t, test.lo, test.hi)
+		if len(got) != 2 || got[0] != test.lo && !math.IsNaN(test.lo) {
+			t.Errorf("ValueTicks(%v, %v) = %v, want the bounds", test.lo, test.hi, got)
+		}
+	}
+}
+
+// valueTicks calls ValueTicks with 5 ticks, failing if it doesn't return in a second
+func valueTicks(t *testing.T, lo, hi float64) []float64 {
+	t.Helper()
+	done := make(chan []float64, 1)
+	go func() { done <- ValueTicks(lo, hi, 5) }()
+	select {
+	case ticks := <-done:
+		if len(ticks) > maxValueTicks {
+			t.Errorf("ValueTicks(%v, %v) returned %d ticks", lo, hi, len(ticks))
+		}
+		return ticks
+	case <-time.After(time.Second):
+		t.Fatalf("ValueTicks(%v, %v) doesn't return", lo, hi)
+		return nil
+	}
+}
@@ -4,67 +4,29 @@
 package charts
 
 import (
-	"fmt"
-
-	"github.com/vicanso/go-charts/v2"
-
-	"github.com/dcvix/dcvix-stats/internal/logparser"
+	"time"
 )
 
-func ChartByMetricList(metrics map[string][]logparser.LogEntry, width float32, height float32) []byte {
-	var values [][]float64
-	var timeStamps []string
-
-	for _, entries := range metrics {
-		var metricValues []float64
-		var metricTimeStamps []string
-
-		for _, entry := range entries {
-			metricValues = append(metricValues, entry.LastValue)
-			metricTimeStamps = append(metricTimeStamps, entry.Timestamp)
-		}
-
-		values = append(values, metricValues)
-		timeStamps = append(timeStamps, metricTimeStamps...)
-	}
-
-	return Chart([]string{""}, values, timeStamps, width, height)
+// Options customize the rendering of a chart
+type Options struct {
+	// Unit is appended to the y axis values
+	Unit string
+	// Origin, when set, labels the x axis with the time elapsed since it instead of the time of day
+	Origin time.Time
+	// Type is the chart type, Line if empty
+	Type ChartType
+	// YMin and YMax fix the bounds of the y axis, automatic if nil
+	YMin *float64
+	YMax *float64
 }
 
-func Chart(metrics []string, values [][]float64, timeStamps []string, width float32, height float32) []byte {
-	p, err := charts.LineRender(
-		values,
-		// charts.TitleTextOptionFunc("Line"),
-		charts.XAxisDataOptionFunc(timeStamps),
-		charts.LegendLabelsOptionFunc(metrics, "100"),
-		func(opt *charts.ChartOption) {
-			opt.Theme = "grafana"
-			opt.Legend.Padding = charts.Box{
-				Top:    5,
-				Bottom: 10,
-			}
-			opt.YAxisOptions = []charts.YAxisOption{
-				{
-					SplitLineShow: charts.FalseFlag(),
-				},
-			}
-			opt.SymbolShow = charts.FalseFlag()
-			opt.LineStrokeWidth = 1
-			opt.ValueFormatter = func(f float64) string {
-				return fmt.Sprintf("%.0f", f)
-			}
-			opt.Width = int(width)
-			opt.Height = int(height)
-		},
-	)
-
-	if err != nil {
-		panic(err)
-	}
+// ChartType selects how the series are drawn
+type ChartType string
 
-	buf, err := p.Bytes()
-	if err != nil {
-		panic(err)
-	}
-	return buf
-}
+const (
+	Line ChartType = "line"
+	// Step draws every value as a horizontal line up to the next sample
+	Step ChartType = "step"
+	// Bar draws a bar for every value, the bars of the series side by side
+	Bar ChartType = "bar"
+)
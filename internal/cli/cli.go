@@ -0,0 +1,140 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+// Package cli implements the subcommands that work without a display
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/globals"
+	"github.com/dcvix/dcvix-stats/internal/logger"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// commands maps the subcommand names to their implementation, args don't include the name
+var commands = map[string]func(args []string) error{
+	"summary": runSummary,
+	"export":  runExport,
+	"serve":   runServe,
+	"otlp":    runOTLP,
+	"stream":  runStream,
+}
+
+// IsCommand reports whether name is a subcommand
+func IsCommand(name string) bool {
+	_, found := commands[name]
+	return found
+}
+
+// Commands returns the names of the subcommands, sorted
+func Commands() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run executes the subcommand name with its command-line arguments
+func Run(name string, args []string) error {
+	command, found := commands[name]
+	if !found {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return command(args)
+}
+
+// RegisterLogFlags adds to fs the flags selecting and parsing the log file,
+// shared by the GUI and the subcommands
+func RegisterLogFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&globals.Verbose, "verbose", false, "Enable verbose logging")
+	fs.IntVar(&globals.LogEntriesQty, "entries", 120, "How many last entries/minutes to evaluate")
+	fs.StringVar(&globals.LogFile, "logfile", DefaultLogPath(),
+		"DCV server log: a file, a glob pattern or directory, - for stdin, syslog+udp://:514 or syslog+tcp://:514")
+	fs.BoolVar(&globals.ReadRotatedLogs, "rotated", true, "Read rotated log files (server.log.1, server.log.2.gz, ...) to fill the entries window")
+	fs.BoolVar(&globals.DiscoverMetrics, "discover", true, "Show metrics found in the log but not in the metric catalog")
+}
+
+// DefaultLogPath returns where DCV writes its server log
+func DefaultLogPath() string {
+	if runtime.GOOS == "windows" {
+		return `C:\ProgramData\NICE\dcv\log\server.log`
+	}
+	return "/var/log/dcv/server.log"
+}
+
+// newFlagSet returns the flag set of a subcommand, with the log flags already registered
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	RegisterLogFlags(fs)
+	return fs
+}
+
+// loadLog loads the metric catalog and parses the log file
+func loadLog() (*logparser.LogParser, *catalog.Catalog, error) {
+	cat, err := catalog.Load(userCatalogPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.LogVerbosef("Reading log file: %s\n", globals.LogFile)
+	parser, err := newParser(cat)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Commands reading the log once need all of standard input
+	if reader, ok := parser.Source().(*logparser.ReaderSource); ok {
+		reader.Wait()
+	}
+	if err := parser.ReadLogFile(); err != nil {
+		return nil, nil, err
+	}
+	return parser, cat, nil
+}
+
+// newParser returns a parser of the log source given by --logfile
+func newParser(cat *catalog.Catalog) (*logparser.LogParser, error) {
+	source, err := logparser.OpenSource(globals.LogFile, globals.ReadRotatedLogs)
+	if err != nil {
+		return nil, err
+	}
+	parser := logparser.NewLogParser(source, cat.MetricNames())
+	parser.SetDiscovery(globals.DiscoverMetrics)
+	return parser, nil
+}
+
+// refreshInterval returns the --refresh flag of the commands reading the log periodically
+func refreshInterval(seconds int) (time.Duration, error) {
+	if seconds < 1 {
+		return 0, fmt.Errorf("invalid --refresh %d, the log can be read at most once a second", seconds)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// stopContext returns a context cancelled when the process is interrupted or asked to
+// terminate, e.g. by systemd
+func stopContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// userCatalogPath returns the path of the user catalog without starting the GUI,
+// it matches the Fyne preferences directory on Linux and Windows
+func userCatalogPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "fyne", globals.AppID, catalog.FileName)
+}
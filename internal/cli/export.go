@@ -0,0 +1,68 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dcvix/dcvix-stats/internal/export"
+	"github.com/dcvix/dcvix-stats/internal/globals"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// runExport writes the entries of the log as CSV or JSON
+func runExport(args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", "csv", "Output format: csv or json")
+	metrics := fs.String("metrics", "", "Comma separated metrics to export, all if empty")
+	connection := fs.Int("connection", logparser.AllConnections, "Connection to export, all if negative")
+	from := fs.String("from", "", "Export entries from this time (e.g. \"2025-09-26 10:00\"), default --entries minutes before --to")
+	to := fs.String("to", "", "Export entries up to this time, default the end of the log")
+	output := fs.String("output", "", "Output file, standard output if empty")
+	fs.Parse(args)
+
+	exportFormat, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	sel := export.Selection{Connection: *connection}
+	if *connection < 0 {
+		sel.Connection = logparser.AllConnections
+	}
+	if *metrics != "" {
+		sel.Metrics = strings.Split(*metrics, ",")
+	}
+	if sel.From, err = export.ParseTime(*from); err != nil {
+		return err
+	}
+	if sel.To, err = export.ParseTime(*to); err != nil {
+		return err
+	}
+
+	parser, _, err := loadLog()
+	if err != nil {
+		return err
+	}
+	// Without --from export the entries window ending at --to, or at the last entry
+	end := sel.To
+	if end.IsZero() {
+		end = parser.LastTimestamp()
+	}
+	if sel.From.IsZero() && !end.IsZero() {
+		sel.From = end.Add(-time.Duration(globals.LogEntriesQty) * time.Minute)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	return export.Write(out, exportFormat, export.Entries(parser, sel))
+}
@@ -0,0 +1,67 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"os"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/exporter"
+	"github.com/dcvix/dcvix-stats/internal/globals"
+	"github.com/dcvix/dcvix-stats/internal/logger"
+)
+
+// runOTLP tails the log and sends every new entry to an OpenTelemetry collector
+func runOTLP(args []string) error {
+	fs := newFlagSet("otlp")
+	protocol := fs.String("protocol", exporter.OTLPGRPC, "OTLP protocol: grpc or http (protobuf)")
+	endpoint := fs.String("endpoint", "", "Collector URL, default http://localhost:4317 for grpc and http://localhost:4318 for http")
+	host := fs.String("host", "", "Host name reported in the resource attributes, default the local host name")
+	serverVersion := fs.String("dcv-version", "", "DCV server version reported in the resource attributes, default the one found in the log")
+	refresh := fs.Int("refresh", 30, "Log read interval in seconds")
+	fs.Parse(args)
+
+	interval, err := refreshInterval(*refresh)
+	if err != nil {
+		return err
+	}
+
+	if *endpoint == "" {
+		*endpoint = "http://localhost:4317"
+		if *protocol == exporter.OTLPHTTP {
+			*endpoint = "http://localhost:4318"
+		}
+	}
+	if *host == "" {
+		*host, _ = os.Hostname()
+	}
+
+	cat, err := catalog.Load(userCatalogPath())
+	if err != nil {
+		return err
+	}
+	parser, err := newParser(cat)
+	if err != nil {
+		return err
+	}
+	defer parser.Close()
+
+	ctx, stop := stopContext()
+	defer stop()
+
+	client, err := exporter.NewOTLPClient(ctx, *protocol, *endpoint)
+	if err != nil {
+		return err
+	}
+	otlp := exporter.NewOTLPExporter(client, cat, *host, func() string {
+		if *serverVersion != "" {
+			return *serverVersion
+		}
+		return parser.ServerVersion()
+	})
+
+	logger.LogVerbosef("Sending metrics of %s to %s\n", globals.LogFile, *endpoint)
+	exporter.NewFeed(parser, interval, otlp).Run(ctx)
+	return nil
+}
@@ -0,0 +1,70 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/exporter"
+	"github.com/dcvix/dcvix-stats/internal/globals"
+	"github.com/dcvix/dcvix-stats/internal/logger"
+)
+
+// runServe tails the log and serves the latest values of every metric on /metrics
+func runServe(args []string) error {
+	fs := newFlagSet("serve")
+	listen := fs.String("listen", ":9877", "Address to serve the Prometheus /metrics endpoint on")
+	refresh := fs.Int("refresh", 15, "Log read interval in seconds")
+	fs.Parse(args)
+
+	interval, err := refreshInterval(*refresh)
+	if err != nil {
+		return err
+	}
+
+	cat, err := catalog.Load(userCatalogPath())
+	if err != nil {
+		return err
+	}
+	parser, err := newParser(cat)
+	if err != nil {
+		return err
+	}
+	defer parser.Close()
+
+	feed := exporter.NewFeed(parser, interval)
+	collector := exporter.NewPrometheusCollector(cat, feed)
+	feed.AddSink(collector)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: *listen, Handler: mux}
+
+	ctx, stop := stopContext()
+	defer stop()
+
+	go feed.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.LogVerbosef("Serving metrics of %s on %s/metrics\n", globals.LogFile, *listen)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
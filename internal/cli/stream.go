@@ -0,0 +1,65 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"errors"
+	"os"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/exporter"
+	"github.com/dcvix/dcvix-stats/internal/globals"
+	"github.com/dcvix/dcvix-stats/internal/logger"
+)
+
+// runStream tails the log and writes every new entry to the sinks given with --sink
+func runStream(args []string) error {
+	fs := newFlagSet("stream")
+	var specs []string
+	fs.Func("sink", "Output, repeatable: influx:-, influx:FILE, influx:URL, statsd:HOST:PORT or dogstatsd:HOST:PORT", func(spec string) error {
+		specs = append(specs, spec)
+		return nil
+	})
+	host := fs.String("host", "", "Host name tagging the entries, default the local host name")
+	refresh := fs.Int("refresh", 30, "Log read interval in seconds")
+	fs.Parse(args)
+
+	interval, err := refreshInterval(*refresh)
+	if err != nil {
+		return err
+	}
+
+	if len(specs) == 0 {
+		return errors.New("no sink given, use --sink")
+	}
+	if *host == "" {
+		*host, _ = os.Hostname()
+	}
+
+	cat, err := catalog.Load(userCatalogPath())
+	if err != nil {
+		return err
+	}
+	parser, err := newParser(cat)
+	if err != nil {
+		return err
+	}
+	defer parser.Close()
+
+	feed := exporter.NewFeed(parser, interval)
+	for _, spec := range specs {
+		sink, err := exporter.ParseSink(spec, *host)
+		if err != nil {
+			return err
+		}
+		feed.AddSink(sink)
+	}
+
+	ctx, stop := stopContext()
+	defer stop()
+
+	logger.LogVerbosef("Streaming metrics of %s to %v\n", globals.LogFile, specs)
+	feed.Run(ctx)
+	return nil
+}
@@ -0,0 +1,115 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/globals"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+	"github.com/dcvix/dcvix-stats/internal/stats"
+)
+
+// summaryRow is the summary of the last values of one metric on one connection
+type summaryRow struct {
+	Connection int    `json:"connection"`
+	Metric     string `json:"metric"`
+	Unit       string `json:"unit,omitempty"`
+	stats.Summary
+}
+
+// runSummary prints the summary of every metric of the last entries of the log
+func runSummary(args []string) error {
+	fs := newFlagSet("summary")
+	format := fs.String("format", "text", "Output format: text, json or csv")
+	fs.Parse(args)
+
+	writers := map[string]func(io.Writer, []summaryRow) error{
+		"text": writeSummaryText,
+		"json": writeSummaryJSON,
+		"csv":  writeSummaryCSV,
+	}
+	write, found := writers[*format]
+	if !found {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	parser, cat, err := loadLog()
+	if err != nil {
+		return err
+	}
+	return write(os.Stdout, summarize(parser, cat))
+}
+
+// summarize returns the summary of the last globals.LogEntriesQty values of every series
+func summarize(parser *logparser.LogParser, cat *catalog.Catalog) []summaryRow {
+	var rows []summaryRow
+	for _, key := range parser.SeriesKeys() {
+		entries := parser.GetEntriesBySeries(key)
+		if len(entries) > globals.LogEntriesQty {
+			entries = entries[len(entries)-globals.LogEntriesQty:]
+		}
+
+		values := make([]float64, len(entries))
+		for i, entry := range entries {
+			values[i] = entry.Last
+		}
+
+		metric, _ := cat.Metric(key.Metric)
+		rows = append(rows, summaryRow{
+			Connection: key.Connection,
+			Metric:     key.Metric,
+			Unit:       metric.Unit,
+			Summary:    stats.Summarize(values),
+		})
+	}
+	return rows
+}
+
+func writeSummaryText(w io.Writer, rows []summaryRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONNECTION\tMETRIC\tUNIT\tSAMPLES\tCURRENT\tAVG\tMIN\tMAX\tP95")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			row.Connection, row.Metric, row.Unit, row.Samples,
+			formatNumber(row.Current), formatNumber(row.Avg), formatNumber(row.Min),
+			formatNumber(row.Max), formatNumber(row.P95))
+	}
+	return tw.Flush()
+}
+
+func writeSummaryJSON(w io.Writer, rows []summaryRow) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if rows == nil {
+		rows = []summaryRow{}
+	}
+	return encoder.Encode(rows)
+}
+
+func writeSummaryCSV(w io.Writer, rows []summaryRow) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"connection", "metric", "unit", "samples", "current", "avg", "min", "max", "p95"})
+	for _, row := range rows {
+		cw.Write([]string{
+			strconv.Itoa(row.Connection), row.Metric, row.Unit, strconv.Itoa(row.Samples),
+			formatNumber(row.Current), formatNumber(row.Avg), formatNumber(row.Min),
+			formatNumber(row.Max), formatNumber(row.P95),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatNumber formats values with at most two decimals
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -0,0 +1,173 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+// Package compare overlays the samples of two logs, or two time ranges of one log,
+// on the time elapsed since the start of each range
+package compare
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+	"github.com/dcvix/dcvix-stats/internal/stats"
+)
+
+// Side is one side of a comparison: the samples of a parser in a time range
+type Side struct {
+	// Label names the side in the series labels, e.g. "A"
+	Label  string
+	Parser *logparser.LogParser
+	// Connection selects the samples of one connection, with logparser.AllConnections
+	// each connection has its own series
+	Connection int
+	// From and To bound the samples, a zero To is the last sample and a zero From
+	// is the entries window of the parser before To
+	From time.Time
+	To   time.Time
+}
+
+// Range returns the time range of the side, resolving the zero bounds
+func (s Side) Range() (time.Time, time.Time) {
+	from, to := s.From, s.To
+	if to.IsZero() {
+		to = s.Parser.LastTimestamp()
+	}
+	if from.IsZero() {
+		from = to.Add(-time.Duration(s.Parser.EntriesQty()) * time.Minute)
+	}
+	return from, to
+}
+
+// connections returns the connections selected on the side
+func (s Side) connections() []int {
+	if s.Connection == logparser.AllConnections {
+		return s.Parser.Connections()
+	}
+	return []int{s.Connection}
+}
+
+// entries returns the samples of metric of a connection on the side, sorted by time
+func (s Side) entries(metric string, connection int) []logparser.LogEntry {
+	from, to := s.Range()
+	return s.Parser.GetEntriesInRange(logparser.SeriesKey{Connection: connection, Metric: metric}, from, to)
+}
+
+// samples returns the samples of metric of all the connections selected on the side
+func (s Side) samples(metric string) []logparser.LogEntry {
+	var entries []logparser.LogEntry
+	for _, connection := range s.connections() {
+		entries = append(entries, s.entries(metric, connection)...)
+	}
+	return entries
+}
+
+// Series returns the series of metrics on side a followed by the ones on side b, one per
+// connection selected on the side. Metric names can carry an aggregate suffix
+// (see logparser.SplitSeriesName).
+// The samples of b are shifted so that both ranges start at origin, the start of a, the
+// time stamps of the rows are on the timeline of a.
+func Series(a, b Side, metrics []string) ([]logparser.Series, []time.Time, time.Time) {
+	origin, _ := a.Range()
+	startB, _ := b.Range()
+	shift := origin.Sub(startB)
+
+	var candidates []logparser.Series
+	var entriesList [][]logparser.LogEntry
+	var aggregates []logparser.Aggregate
+	for i, side := range []Side{a, b} {
+		connections := side.connections()
+		for _, connection := range connections {
+			for _, name := range metrics {
+				metric, agg := logparser.SplitSeriesName(name)
+				entries := side.entries(metric, connection)
+				if len(entries) == 0 {
+					continue
+				}
+				if i == 1 {
+					entries = shifted(entries, shift)
+				}
+				label := name
+				if len(connections) > 1 && connection != 0 {
+					label = fmt.Sprintf("%s #%d", name, connection)
+				}
+				candidates = append(candidates, logparser.Series{
+					Label:     fmt.Sprintf("%s (%s)", label, side.Label),
+					Aggregate: agg,
+				})
+				entriesList = append(entriesList, entries)
+				aggregates = append(aggregates, agg)
+			}
+		}
+	}
+
+	timeStamps, values := logparser.Align(entriesList, aggregates)
+	for i := range candidates {
+		candidates[i].Values = values[i]
+	}
+	return candidates, timeStamps, origin
+}
+
+// shifted returns a copy of entries with their timestamps moved by d
+func shifted(entries []logparser.LogEntry, d time.Duration) []logparser.LogEntry {
+	moved := make([]logparser.LogEntry, len(entries))
+	for i, entry := range entries {
+		entry.Timestamp = entry.Timestamp.Add(d)
+		moved[i] = entry
+	}
+	return moved
+}
+
+// Diff compares the summaries of one series on the two sides
+type Diff struct {
+	Name string
+	A    stats.Summary
+	B    stats.Summary
+}
+
+// AvgChange returns the change of the average from A to B, in percent of A
+func (d Diff) AvgChange() float64 {
+	return change(d.A.Avg, d.B.Avg)
+}
+
+// P95Change returns the change of the 95th percentile from A to B, in percent of A
+func (d Diff) P95Change() float64 {
+	return change(d.A.P95, d.B.P95)
+}
+
+// change returns the relative change from a to b in percent, NaN if a is 0 or missing
+func change(a, b float64) float64 {
+	if a == 0 || math.IsNaN(a) || math.IsNaN(b) {
+		return math.NaN()
+	}
+	return (b - a) / math.Abs(a) * 100
+}
+
+// Summarize returns the differences of each metric between the two sides, skipping
+// the metrics without samples on either side. The samples of all the connections
+// selected on a side are summarized together.
+func Summarize(a, b Side, metrics []string) []Diff {
+	var diffs []Diff
+	for _, name := range metrics {
+		metric, agg := logparser.SplitSeriesName(name)
+		entriesA, entriesB := a.samples(metric), b.samples(metric)
+		if len(entriesA) == 0 && len(entriesB) == 0 {
+			continue
+		}
+		diffs = append(diffs, Diff{
+			Name: name,
+			A:    stats.Summarize(values(entriesA, agg)),
+			B:    stats.Summarize(values(entriesB, agg)),
+		})
+	}
+	return diffs
+}
+
+func values(entries []logparser.LogEntry, agg logparser.Aggregate) []float64 {
+	values := make([]float64, len(entries))
+	for i, entry := range entries {
+		values[i] = entry.Value(agg)
+	}
+	return values
+}
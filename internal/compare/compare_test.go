@@ -0,0 +1,59 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package compare
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+const twoConnections = `2025-09-26 10:39:00,000000 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: 1, last: 1, max: 1, avg: 1.00]
+2025-09-26 10:39:01,000000 [  1139:1139  ] INFO  quictransport - Connection 2 - Stats (1): quic_rtt_nanos: [sum: 5, last: 5, max: 5, avg: 5.00]
+2025-09-26 10:40:00,000000 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: 2, last: 2, max: 2, avg: 2.00]
+2025-09-26 10:40:01,000000 [  1139:1139  ] INFO  quictransport - Connection 2 - Stats (1): quic_rtt_nanos: [sum: 6, last: 6, max: 6, avg: 6.00]
+`
+
+func newParser(t *testing.T, log string) *logparser.LogParser {
+	t.Helper()
+	source := logparser.NewReaderSource("test", strings.NewReader(log))
+	source.Wait()
+	parser := logparser.NewLogParser(source, []string{"quic_rtt_nanos"})
+	if err := parser.ReadLogFile(); err != nil {
+		t.Fatal(err)
+	}
+	return parser
+}
+
+func TestSeriesPerConnection(t *testing.T) {
+	parser := newParser(t, twoConnections)
+	a := Side{Label: "A", Parser: parser, Connection: logparser.AllConnections}
+	b := Side{Label: "B", Parser: parser, Connection: 2}
+
+	series, timeStamps, _ := Series(a, b, []string{"quic_rtt_nanos"})
+	if len(timeStamps) != 2 {
+		t.Fatalf("%d rows, want 2", len(timeStamps))
+	}
+	want := map[string][]float64{
+		"quic_rtt_nanos #1 (A)": {1, 2},
+		"quic_rtt_nanos #2 (A)": {5, 6},
+		"quic_rtt_nanos (B)":    {5, 6},
+	}
+	if len(series) != len(want) {
+		t.Fatalf("%d series, want %d", len(series), len(want))
+	}
+	for _, s := range series {
+		if values, ok := want[s.Label]; !ok || !slices.Equal(s.Values, values) {
+			t.Errorf("series %q = %v, want %v", s.Label, s.Values, values)
+		}
+	}
+
+	// The summary pools the samples of the connections
+	diffs := Summarize(a, b, []string{"quic_rtt_nanos"})
+	if len(diffs) != 1 || diffs[0].A.Avg != 3.5 || diffs[0].B.Avg != 5.5 {
+		t.Errorf("Summarize = %+v, want averages 3.5 and 5.5", diffs)
+	}
+}
@@ -0,0 +1,170 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+// Package export writes parsed log entries as CSV or JSON
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// ParseFormat returns the format named name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case CSV:
+		return CSV, nil
+	case JSON:
+		return JSON, nil
+	}
+	return "", fmt.Errorf("unknown format %q", name)
+}
+
+// timeLayouts are the formats accepted by ParseTime, in local time if the zone is not given
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseTime parses a selection bound like "2025-09-26 10:00", an empty value returns the zero time
+func ParseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// Selection chooses the entries to export
+type Selection struct {
+	// Metrics to export, all if empty. Aggregate suffixes like _avg are ignored since
+	// every entry carries all the aggregates.
+	Metrics []string
+	// Connection to export or logparser.AllConnections
+	Connection int
+	// From and To bound the entry timestamps, a zero time means no bound
+	From time.Time
+	To   time.Time
+}
+
+// Entries returns the entries of parser matching sel, sorted by timestamp, connection and metric
+func Entries(parser *logparser.LogParser, sel Selection) []logparser.LogEntry {
+	metrics := make(map[string]bool)
+	for _, name := range sel.Metrics {
+		metric, _ := logparser.SplitSeriesName(name)
+		metrics[metric] = true
+	}
+
+	var entries []logparser.LogEntry
+	for _, key := range parser.SeriesKeys() {
+		if len(metrics) > 0 && !metrics[key.Metric] {
+			continue
+		}
+		if sel.Connection != logparser.AllConnections && key.Connection != sel.Connection {
+			continue
+		}
+		entries = append(entries, parser.GetEntriesInRange(key, sel.From, sel.To)...)
+	}
+
+	slices.SortStableFunc(entries, func(a, b logparser.LogEntry) int {
+		if c := a.Timestamp.Compare(b.Timestamp); c != 0 {
+			return c
+		}
+		if a.Connection != b.Connection {
+			return a.Connection - b.Connection
+		}
+		return strings.Compare(a.Metric, b.Metric)
+	})
+	return entries
+}
+
+// Write writes entries to w in the given format
+func Write(w io.Writer, format Format, entries []logparser.LogEntry) error {
+	switch format {
+	case CSV:
+		return WriteCSV(w, entries)
+	case JSON:
+		return WriteJSON(w, entries)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// WriteCSV writes entries as CSV with a header row, one row per entry
+func WriteCSV(w io.Writer, entries []logparser.LogEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"timestamp", "connection", "metric", "sum", "last", "max", "avg"})
+	for _, entry := range entries {
+		cw.Write([]string{
+			entry.Timestamp.UTC().Format(time.RFC3339Nano),
+			strconv.Itoa(entry.Connection),
+			entry.Metric,
+			formatValue(entry.Sum),
+			formatValue(entry.Last),
+			formatValue(entry.Max),
+			formatValue(entry.Avg),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonEntry is the JSON representation of a log entry, NaN and infinite values are null
+type jsonEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Connection int       `json:"connection"`
+	Metric     string    `json:"metric"`
+	Sum        *float64  `json:"sum"`
+	Last       *float64  `json:"last"`
+	Max        *float64  `json:"max"`
+	Avg        *float64  `json:"avg"`
+}
+
+// WriteJSON writes entries as a JSON array of objects
+func WriteJSON(w io.Writer, entries []logparser.LogEntry) error {
+	jsonEntries := make([]jsonEntry, len(entries))
+	for i, entry := range entries {
+		jsonEntries[i] = jsonEntry{
+			Timestamp:  entry.Timestamp.UTC(),
+			Connection: entry.Connection,
+			Metric:     entry.Metric,
+			Sum:        jsonValue(entry.Sum),
+			Last:       jsonValue(entry.Last),
+			Max:        jsonValue(entry.Max),
+			Avg:        jsonValue(entry.Avg),
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonEntries)
+}
+
+func formatValue(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// jsonValue returns nil for the values JSON can't represent
+func jsonValue(f float64) *float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	return &f
+}
@@ -0,0 +1,129 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+// Package exporter publishes the parsed log entries to monitoring systems
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dcvix/dcvix-stats/internal/logger"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// Feed reads the log periodically and writes the new entries to its sinks
+type Feed struct {
+	parser   *logparser.LogParser
+	interval time.Duration
+	sinks    []Sink
+
+	// sent is the timestamp of the last entry of each series written to the sinks, entries
+	// parsed out of order before it are not written: the sinks expect increasing timestamps
+	sent map[logparser.SeriesKey]time.Time
+
+	mu         sync.Mutex
+	health     logparser.Health
+	readErrors uint64
+}
+
+// NewFeed returns a feed reading parser every interval. On the first read only the latest
+// entry of each series is written to the sinks, then every new entry.
+// The series of parser are trimmed to its entries window, a feed runs for days.
+func NewFeed(parser *logparser.LogParser, interval time.Duration, sinks ...Sink) *Feed {
+	parser.SetRetention(parser.EntriesQty())
+	return &Feed{
+		parser:   parser,
+		interval: interval,
+		sinks:    sinks,
+	}
+}
+
+// AddSink adds a sink to the feed, it must be called before Run
+func (f *Feed) AddSink(sink Sink) {
+	f.sinks = append(f.sinks, sink)
+}
+
+// Run reads the log right away and then every interval until ctx is done, then closes the sinks
+func (f *Feed) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	defer f.closeSinks()
+
+	for {
+		f.read(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Health returns the parser counters and the number of failed reads of the log
+func (f *Feed) Health() (logparser.Health, uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.health, f.readErrors
+}
+
+func (f *Feed) read(ctx context.Context) {
+	err := f.parser.ReadLogFile()
+
+	f.mu.Lock()
+	f.health = f.parser.Health()
+	if err != nil {
+		f.readErrors++
+	}
+	f.mu.Unlock()
+
+	if err != nil {
+		logger.LogVerbosef("Error reading log file: %v\n", err)
+		return
+	}
+
+	first := f.sent == nil
+	if first {
+		f.sent = make(map[logparser.SeriesKey]time.Time)
+	}
+
+	var entries []logparser.LogEntry
+	for _, key := range f.parser.SeriesKeys() {
+		seriesEntries := f.parser.GetEntriesBySeries(key)
+		if len(seriesEntries) == 0 {
+			continue
+		}
+		start := len(seriesEntries) - 1
+		if sent, ok := f.sent[key]; ok || !first {
+			start, _ = slices.BinarySearchFunc(seriesEntries, sent, func(e logparser.LogEntry, t time.Time) int {
+				if e.Timestamp.After(t) {
+					return 1
+				}
+				return -1
+			})
+		}
+		entries = append(entries, seriesEntries[start:]...)
+		f.sent[key] = seriesEntries[len(seriesEntries)-1].Timestamp
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+	for _, sink := range f.sinks {
+		if err := sink.Write(ctx, entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing to %s: %v\n", sink, err)
+		}
+	}
+}
+
+func (f *Feed) closeSinks() {
+	for _, sink := range f.sinks {
+		if err := sink.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing %s: %v\n", sink, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// influxEscaper escapes measurement names and tag values in the line protocol
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// InfluxSink writes entries in InfluxDB line protocol, one line per entry:
+//
+//	quic_rtt_nanos,host=srv1,connection=3 sum=5000,last=1200.5,max=3000,avg=1100.25 1758883173895170000
+type InfluxSink struct {
+	destination string
+	host        string
+
+	// out is the file or standard output written to, nil for HTTP endpoints
+	out io.WriteCloser
+	// token authenticates the HTTP writes, from the INFLUX_TOKEN environment variable
+	token string
+}
+
+// NewInfluxSink returns a sink writing to destination: "-" for standard output,
+// an http(s) URL for a write endpoint, a file path otherwise
+func NewInfluxSink(destination string, host string) (*InfluxSink, error) {
+	s := &InfluxSink{destination: destination, host: host}
+
+	switch {
+	case destination == "-":
+		s.out = nopCloser{os.Stdout}
+	case strings.HasPrefix(destination, "http://") || strings.HasPrefix(destination, "https://"):
+		s.token = os.Getenv("INFLUX_TOKEN")
+	default:
+		file, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		s.out = file
+	}
+	return s, nil
+}
+
+func (s *InfluxSink) Write(ctx context.Context, entries []logparser.LogEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		s.appendLine(&buf, entry)
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	if s.out != nil {
+		_, err := s.out.Write(buf.Bytes())
+		return err
+	}
+	return s.post(ctx, &buf)
+}
+
+func (s *InfluxSink) Close() error {
+	if s.out != nil {
+		return s.out.Close()
+	}
+	return nil
+}
+
+func (s *InfluxSink) String() string {
+	return "influx:" + s.destination
+}
+
+// appendLine writes the line of entry to buf, NaN and infinite values are left out
+// since the line protocol can't represent them
+func (s *InfluxSink) appendLine(buf *bytes.Buffer, entry logparser.LogEntry) {
+	var fields []string
+	for _, agg := range aggregateLabels {
+		value := entry.Value(agg.aggregate)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		fields = append(fields, agg.name+"="+strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	buf.WriteString(influxEscaper.Replace(entry.Metric))
+	if s.host != "" {
+		buf.WriteString(",host=" + influxEscaper.Replace(s.host))
+	}
+	fmt.Fprintf(buf, ",connection=%d %s %d\n", entry.Connection, strings.Join(fields, ","), entry.Timestamp.UnixNano())
+}
+
+// influxTimeout bounds a post to the write endpoint, a hung endpoint must not block the feed
+const influxTimeout = 10 * time.Second
+
+// post sends the lines to the HTTP write endpoint
+func (s *InfluxSink) post(ctx context.Context, body io.Reader) error {
+	ctx, cancel := context.WithTimeout(ctx, influxTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.destination, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("write failed: %s %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// nopCloser keeps standard output open when the sink is closed
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
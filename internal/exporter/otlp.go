@@ -0,0 +1,149 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+	"github.com/dcvix/dcvix-stats/internal/version"
+)
+
+// OTLP protocols
+const (
+	OTLPGRPC = "grpc"
+	OTLPHTTP = "http"
+)
+
+// otlpUnits maps catalog units to UCUM units
+var otlpUnits = map[string]string{
+	"ns":      "ns",
+	"bytes":   "By",
+	"bytes/s": "By/s",
+}
+
+// NewOTLPClient returns an OTLP metric exporter sending to endpoint, a URL like
+// http://localhost:4317, with protocol OTLPGRPC or OTLPHTTP (protobuf over HTTP)
+func NewOTLPClient(ctx context.Context, protocol string, endpoint string) (sdkmetric.Exporter, error) {
+	switch protocol {
+	case OTLPGRPC:
+		return otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithEndpointURL(endpoint))
+	case OTLPHTTP:
+		return otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint))
+	}
+	return nil, fmt.Errorf("unknown OTLP protocol %q", protocol)
+}
+
+// OTLPExporter converts log entries into OTLP gauges named dcv.<metric>, one data point
+// per entry and aggregate, keeping the log timestamps.
+// Each connection is exported as its own resource with the dcv.connection.id attribute.
+type OTLPExporter struct {
+	client        sdkmetric.Exporter
+	cat           *catalog.Catalog
+	host          string
+	serverVersion func() string
+}
+
+// NewOTLPExporter returns an exporter sending through client the entries of the DCV server
+// with the given host name, serverVersion returns the server version or "" if unknown
+func NewOTLPExporter(client sdkmetric.Exporter, cat *catalog.Catalog, host string, serverVersion func() string) *OTLPExporter {
+	return &OTLPExporter{
+		client:        client,
+		cat:           cat,
+		host:          host,
+		serverVersion: serverVersion,
+	}
+}
+
+// Write sends entries to the collector
+func (e *OTLPExporter) Write(ctx context.Context, entries []logparser.LogEntry) error {
+	byConnection := make(map[int][]logparser.LogEntry)
+	for _, entry := range entries {
+		byConnection[entry.Connection] = append(byConnection[entry.Connection], entry)
+	}
+
+	var errs []error
+	for connection, connectionEntries := range byConnection {
+		rm := e.resourceMetrics(connection, connectionEntries)
+		if err := e.client.Export(ctx, rm); err != nil {
+			errs = append(errs, fmt.Errorf("connection %d: %w", connection, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes and closes the connection to the collector
+func (e *OTLPExporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return e.client.Shutdown(ctx)
+}
+
+func (e *OTLPExporter) String() string {
+	return "otlp"
+}
+
+// resourceMetrics converts the entries of one connection
+func (e *OTLPExporter) resourceMetrics(connection int, entries []logparser.LogEntry) *metricdata.ResourceMetrics {
+	attributes := []attribute.KeyValue{
+		attribute.String("service.name", "dcv-server"),
+		attribute.String("host.name", e.host),
+		attribute.Int("dcv.connection.id", connection),
+	}
+	if serverVersion := e.serverVersion(); serverVersion != "" {
+		attributes = append(attributes, attribute.String("dcv.server.version", serverVersion))
+	}
+
+	var metrics []metricdata.Metrics
+	index := make(map[string]int)
+	for _, entry := range entries {
+		i, found := index[entry.Metric]
+		if !found {
+			i = len(metrics)
+			index[entry.Metric] = i
+			metric, _ := e.cat.Metric(entry.Metric)
+			metrics = append(metrics, metricdata.Metrics{
+				Name:        "dcv." + entry.Metric,
+				Description: metric.Description,
+				Unit:        otlpUnits[metric.Unit],
+				Data:        metricdata.Gauge[float64]{},
+			})
+		}
+
+		gauge := metrics[i].Data.(metricdata.Gauge[float64])
+		for _, agg := range aggregateLabels {
+			gauge.DataPoints = append(gauge.DataPoints, metricdata.DataPoint[float64]{
+				Attributes: attribute.NewSet(attribute.String("aggregate", agg.name)),
+				Time:       entry.Timestamp,
+				Value:      entry.Value(agg.aggregate),
+			})
+		}
+		metrics[i].Data = gauge
+	}
+
+	return &metricdata.ResourceMetrics{
+		Resource: resource.NewSchemaless(attributes...),
+		ScopeMetrics: []metricdata.ScopeMetrics{
+			{
+				Scope: instrumentation.Scope{
+					Name:    "github.com/dcvix/dcvix-stats",
+					Version: version.Short(),
+				},
+				Metrics: metrics,
+			},
+		},
+	}
+}
@@ -0,0 +1,150 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// fakeCollector is an in-process stand-in for an OTLP collector, recording the exports
+type fakeCollector struct {
+	exported []*metricdata.ResourceMetrics
+}
+
+func (c *fakeCollector) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (c *fakeCollector) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (c *fakeCollector) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	c.exported = append(c.exported, rm)
+	return nil
+}
+
+func (c *fakeCollector) ForceFlush(context.Context) error { return nil }
+
+func (c *fakeCollector) Shutdown(context.Context) error { return nil }
+
+func TestOTLPExporterWrite(t *testing.T) {
+	cat, err := catalog.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &fakeCollector{}
+	exporter := NewOTLPExporter(collector, cat, "dcv-host", func() string { return "2024.0.17979" })
+
+	timestamp := time.Date(2025, 9, 26, 10, 39, 33, 0, time.UTC)
+	entries := []logparser.LogEntry{
+		{Timestamp: timestamp, Connection: 3, Metric: "quic_rtt_nanos", Sum: 40, Last: 10, Max: 20, Avg: 15},
+		{Timestamp: timestamp.Add(time.Minute), Connection: 3, Metric: "quic_rtt_nanos", Sum: 50, Last: 11, Max: 21, Avg: 16},
+		{Timestamp: timestamp, Connection: 4, Metric: "quic_lost_packets", Sum: 1, Last: 2, Max: 3, Avg: 4},
+	}
+	if err := exporter.Write(context.Background(), entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(collector.exported) != 2 {
+		t.Fatalf("got %d exports, want one per connection", len(collector.exported))
+	}
+
+	for _, rm := range collector.exported {
+		resource := rm.Resource.Set()
+		connection, ok := resource.Value("dcv.connection.id")
+		if !ok {
+			t.Fatal("missing dcv.connection.id resource attribute")
+		}
+		if host, _ := resource.Value("host.name"); host.AsString() != "dcv-host" {
+			t.Errorf("host.name = %q, want dcv-host", host.AsString())
+		}
+		if serverVersion, _ := resource.Value("dcv.server.version"); serverVersion.AsString() != "2024.0.17979" {
+			t.Errorf("dcv.server.version = %q, want 2024.0.17979", serverVersion.AsString())
+		}
+
+		if len(rm.ScopeMetrics) != 1 || len(rm.ScopeMetrics[0].Metrics) != 1 {
+			t.Fatalf("connection %d: want a single metric", connection.AsInt64())
+		}
+		metric := rm.ScopeMetrics[0].Metrics[0]
+		gauge, ok := metric.Data.(metricdata.Gauge[float64])
+		if !ok {
+			t.Fatalf("%s is %T, want a float64 gauge", metric.Name, metric.Data)
+		}
+
+		switch connection.AsInt64() {
+		case 3:
+			if metric.Name != "dcv.quic_rtt_nanos" || metric.Unit != "ns" {
+				t.Errorf("got metric %s in %q, want dcv.quic_rtt_nanos in ns", metric.Name, metric.Unit)
+			}
+			want := map[time.Time]map[string]float64{
+				timestamp:                  {"last": 10, "avg": 15, "max": 20, "sum": 40},
+				timestamp.Add(time.Minute): {"last": 11, "avg": 16, "max": 21, "sum": 50},
+			}
+			checkDataPoints(t, gauge.DataPoints, want)
+		case 4:
+			if metric.Name != "dcv.quic_lost_packets" {
+				t.Errorf("got metric %s, want dcv.quic_lost_packets", metric.Name)
+			}
+			want := map[time.Time]map[string]float64{
+				timestamp: {"last": 2, "avg": 4, "max": 3, "sum": 1},
+			}
+			checkDataPoints(t, gauge.DataPoints, want)
+		default:
+			t.Errorf("unexpected connection %d", connection.AsInt64())
+		}
+	}
+}
+
+func TestOTLPExporterUnknownVersion(t *testing.T) {
+	cat, err := catalog.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &fakeCollector{}
+	exporter := NewOTLPExporter(collector, cat, "dcv-host", func() string { return "" })
+
+	entries := []logparser.LogEntry{{Timestamp: time.Now(), Metric: "quic_rtt_nanos"}}
+	if err := exporter.Write(context.Background(), entries); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := collector.exported[0].Resource.Set().Value("dcv.server.version"); ok {
+		t.Error("dcv.server.version set while the version is unknown")
+	}
+}
+
+// checkDataPoints checks that points holds exactly one point per timestamp and aggregate of want
+func checkDataPoints(t *testing.T, points []metricdata.DataPoint[float64], want map[time.Time]map[string]float64) {
+	t.Helper()
+	count := 0
+	for _, values := range want {
+		count += len(values)
+	}
+	if len(points) != count {
+		t.Errorf("got %d data points, want %d", len(points), count)
+	}
+	for _, point := range points {
+		aggregate, ok := point.Attributes.Value(attribute.Key("aggregate"))
+		if !ok {
+			t.Errorf("data point at %v without aggregate attribute", point.Time)
+			continue
+		}
+		value, ok := want[point.Time][aggregate.AsString()]
+		if !ok {
+			t.Errorf("unexpected data point %s at %v", aggregate.AsString(), point.Time)
+			continue
+		}
+		if point.Value != value {
+			t.Errorf("%s at %v = %v, want %v", aggregate.AsString(), point.Time, point.Value, value)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package exporter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// staleAfter is how long a series is still exported after its last sample, compared to
+// the most recent sample in the log, so that closed connections go away
+const staleAfter = 5 * time.Minute
+
+var aggregateLabels = []struct {
+	name      string
+	aggregate logparser.Aggregate
+}{
+	{"last", logparser.Last},
+	{"avg", logparser.Avg},
+	{"max", logparser.Max},
+	{"sum", logparser.Sum},
+}
+
+var (
+	linesReadDesc = prometheus.NewDesc("dcvix_stats_lines_read_total",
+		"Log lines read.", nil, nil)
+	linesRejectedDesc = prometheus.NewDesc("dcvix_stats_lines_rejected_total",
+		"Stats log lines that could not be parsed.", nil, nil)
+	readErrorsDesc = prometheus.NewDesc("dcvix_stats_read_errors_total",
+		"Failed reads of the log file.", nil, nil)
+	lastParseDesc = prometheus.NewDesc("dcvix_stats_last_parse_timestamp_seconds",
+		"Time of the last successful read of the log file.", nil, nil)
+	lastSampleDesc = prometheus.NewDesc("dcvix_stats_last_sample_timestamp_seconds",
+		"Log timestamp of the latest stats line of a connection.", []string{"connection"}, nil)
+)
+
+// PrometheusCollector exposes the latest value of every metric as gauges named
+// dcv_<metric>, labelled by connection and aggregate, plus the feed health counters
+type PrometheusCollector struct {
+	cat  *catalog.Catalog
+	feed *Feed
+
+	mu     sync.Mutex
+	latest map[logparser.SeriesKey]logparser.LogEntry
+}
+
+// NewPrometheusCollector returns a collector describing metrics with cat, it must be
+// added as a sink to feed to stay current
+func NewPrometheusCollector(cat *catalog.Catalog, feed *Feed) *PrometheusCollector {
+	return &PrometheusCollector{
+		cat:    cat,
+		feed:   feed,
+		latest: make(map[logparser.SeriesKey]logparser.LogEntry),
+	}
+}
+
+// Write records the latest entry of each series
+func (c *PrometheusCollector) Write(ctx context.Context, entries []logparser.LogEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range entries {
+		key := logparser.SeriesKey{Connection: entry.Connection, Metric: entry.Metric}
+		if entry.Timestamp.After(c.latest[key].Timestamp) {
+			c.latest[key] = entry
+		}
+	}
+	return nil
+}
+
+func (c *PrometheusCollector) Close() error {
+	return nil
+}
+
+func (c *PrometheusCollector) String() string {
+	return "prometheus"
+}
+
+// Describe sends no descriptors: metrics depend on the log content, so the collector is unchecked
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	health, readErrors := c.feed.Health()
+	ch <- prometheus.MustNewConstMetric(linesReadDesc, prometheus.CounterValue, float64(health.LinesRead))
+	ch <- prometheus.MustNewConstMetric(linesRejectedDesc, prometheus.CounterValue, float64(health.LinesRejected))
+	ch <- prometheus.MustNewConstMetric(readErrorsDesc, prometheus.CounterValue, float64(readErrors))
+	if !health.LastRead.IsZero() {
+		ch <- prometheus.MustNewConstMetric(lastParseDesc, prometheus.GaugeValue, float64(health.LastRead.UnixNano())/1e9)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var newest time.Time
+	for _, entry := range c.latest {
+		if entry.Timestamp.After(newest) {
+			newest = entry.Timestamp
+		}
+	}
+
+	descs := make(map[string]*prometheus.Desc)
+	lastSamples := make(map[int]time.Time)
+	for key, entry := range c.latest {
+		if newest.Sub(entry.Timestamp) > staleAfter {
+			continue
+		}
+		if entry.Timestamp.After(lastSamples[key.Connection]) {
+			lastSamples[key.Connection] = entry.Timestamp
+		}
+
+		desc, found := descs[key.Metric]
+		if !found {
+			desc = c.metricDesc(key.Metric)
+			descs[key.Metric] = desc
+		}
+		connection := strconv.Itoa(key.Connection)
+		for _, agg := range aggregateLabels {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, entry.Value(agg.aggregate), connection, agg.name)
+		}
+	}
+
+	for connection, ts := range lastSamples {
+		ch <- prometheus.MustNewConstMetric(lastSampleDesc, prometheus.GaugeValue, float64(ts.UnixNano())/1e9, strconv.Itoa(connection))
+	}
+}
+
+// metricDesc describes the gauge of a DCV metric, using the catalog description as help
+func (c *PrometheusCollector) metricDesc(metric string) *prometheus.Desc {
+	help := "DCV stats " + metric + "."
+	if m, found := c.cat.Metric(metric); found && m.Description != "" {
+		help = m.Description
+		if m.Unit != "" {
+			help += " (" + m.Unit + ")"
+		}
+		help += "."
+	}
+	return prometheus.NewDesc("dcv_"+sanitizeMetricName(metric), help, []string{"connection", "aggregate"}, nil)
+}
+
+// sanitizeMetricName replaces the characters not allowed in Prometheus metric names
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,48 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// Sink is an output of the entries read by a Feed
+type Sink interface {
+	// Write publishes entries, sorted by series and time
+	Write(ctx context.Context, entries []logparser.LogEntry) error
+	// Close flushes pending data and releases the sink resources
+	Close() error
+	// String describes the sink in error messages
+	String() string
+}
+
+// ParseSink returns the sink described by spec, in the form "<type>:<destination>":
+//
+//	influx:-                       InfluxDB line protocol on standard output
+//	influx:/path/to/file           InfluxDB line protocol appended to a file
+//	influx:http://host:8086/...    InfluxDB line protocol posted to a write endpoint
+//	statsd:host:8125               StatsD gauges over UDP
+//	dogstatsd:host:8125            DogStatsD gauges with tags over UDP
+//
+// host tags the published entries with the DCV server host name.
+func ParseSink(spec string, host string) (Sink, error) {
+	kind, destination, found := strings.Cut(spec, ":")
+	if !found || destination == "" {
+		return nil, fmt.Errorf("invalid sink %q, expected <type>:<destination>", spec)
+	}
+
+	switch kind {
+	case "influx":
+		return NewInfluxSink(destination, host)
+	case "statsd":
+		return NewStatsdSink(destination, host, false)
+	case "dogstatsd":
+		return NewStatsdSink(destination, host, true)
+	}
+	return nil, fmt.Errorf("unknown sink type %q", kind)
+}
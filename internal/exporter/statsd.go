@@ -0,0 +1,115 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// statsdMaxPacket keeps UDP packets within the usual network MTU
+const statsdMaxPacket = 1432
+
+// StatsdSink sends entries as StatsD gauges over UDP, one gauge per aggregate.
+// Plain StatsD has no tags, so the connection is part of the name:
+//
+//	dcv.conn3.quic_rtt_nanos.last:1200.5|g
+//
+// DogStatsD uses tags instead:
+//
+//	dcv.quic_rtt_nanos:1200.5|g|#connection:3,aggregate:last,host:srv1
+type StatsdSink struct {
+	address string
+	host    string
+	tagged  bool
+	conn    net.Conn
+}
+
+// NewStatsdSink returns a sink sending to the StatsD server at address (host:port),
+// with tagged the DogStatsD tag extension is used
+func NewStatsdSink(address string, host string, tagged bool) (*StatsdSink, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsdSink{address: address, host: host, tagged: tagged, conn: conn}, nil
+}
+
+func (s *StatsdSink) Write(ctx context.Context, entries []logparser.LogEntry) error {
+	var packet []byte
+	for _, entry := range entries {
+		for _, agg := range aggregateLabels {
+			value := entry.Value(agg.aggregate)
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+			line := s.line(entry, agg.name, value)
+
+			if len(packet) > 0 && len(packet)+1+len(line) > statsdMaxPacket {
+				if _, err := s.conn.Write(packet); err != nil {
+					return err
+				}
+				packet = packet[:0]
+			}
+			if len(packet) > 0 {
+				packet = append(packet, '\n')
+			}
+			packet = append(packet, line...)
+		}
+	}
+
+	if len(packet) > 0 {
+		_, err := s.conn.Write(packet)
+		return err
+	}
+	return nil
+}
+
+func (s *StatsdSink) Close() error {
+	return s.conn.Close()
+}
+
+func (s *StatsdSink) String() string {
+	if s.tagged {
+		return "dogstatsd:" + s.address
+	}
+	return "statsd:" + s.address
+}
+
+// line returns the gauge of one aggregate of entry
+func (s *StatsdSink) line(entry logparser.LogEntry, aggregate string, value float64) string {
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	metric := statsdName(entry.Metric)
+	if !s.tagged {
+		name := fmt.Sprintf("dcv.conn%d.%s.%s", entry.Connection, metric, aggregate)
+		if value < 0 {
+			// A signed value changes the gauge by that amount, set it to zero first
+			return fmt.Sprintf("%s:0|g\n%s:%s|g", name, name, formatted)
+		}
+		return fmt.Sprintf("%s:%s|g", name, formatted)
+	}
+
+	tags := fmt.Sprintf("connection:%d,aggregate:%s", entry.Connection, aggregate)
+	if s.host != "" {
+		tags += ",host:" + statsdName(s.host)
+	}
+	return fmt.Sprintf("dcv.%s:%s|g|#%s", metric, formatted, tags)
+}
+
+// statsdName replaces the characters with a meaning in the StatsD protocol
+func statsdName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -4,109 +4,639 @@
 package gui
 
 import (
-	"bytes"
-	"image"
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
-	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/dcvix/dcvix-stats/internal/charts"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
 )
 
 // Ensure ChartView implements fyne.Widget
 // emit compile error if interface is not implemented (cast nil to interface)
 var _ fyne.Widget = (*ChartView)(nil)
+var _ desktop.Hoverable = (*ChartView)(nil)
+var _ fyne.Scrollable = (*ChartView)(nil)
+var _ fyne.Draggable = (*ChartView)(nil)
+var _ fyne.DoubleTappable = (*ChartView)(nil)
 
 // const minSizeW = 512
 // const minSizeH = 284
 const minSizeW = 500
 const minSizeH = 200
 
-// Add more to implement more interfaces for example:
-// var _ fyne.Draggable = (*ChartView)(nil)
+// Chart drawing sizes, in Fyne units so that they scale on HiDPI screens
+const (
+	chartPadding     = 8
+	chartStrokeWidth = 1.5
+	// chartTickSpacing is the minimum space between two labels of the time axis
+	chartTickSpacing = 90
+	// chartDash and chartGap are the lengths of the dashes of max and sum series
+	chartDash = 4
+	chartGap  = 3
+	// minZoomSpan is the shortest time range a chart can be zoomed to
+	minZoomSpan = 5 * time.Minute
+	// zoomFactor scales the time range at every scroll step
+	zoomFactor = 0.8
+)
+
+// palette are the colors of the series, the ones of the Grafana dashboards
+var palette = []color.NRGBA{
+	{0x7e, 0xb2, 0x6d, 0xff}, {0xea, 0xb8, 0x39, 0xff}, {0x6e, 0xd0, 0xe0, 0xff}, {0xef, 0x84, 0x3c, 0xff},
+	{0xe2, 0x4d, 0x42, 0xff}, {0x1f, 0x78, 0xc1, 0xff}, {0xba, 0x43, 0xa6, 0xff}, {0x70, 0x5d, 0xa0, 0xff},
+}
 
-// ChartView is a widget that displays an image.
+// ChartView is a widget drawing a line chart with the Fyne canvas primitives.
+// Hovering shows the values under the mouse, scrolling zooms the time axis, dragging pans
+// it and a double click shows all the samples again. The cursor and the time range are
+// shared with the other charts of the same TimeSelection.
 type ChartView struct {
 	widget.BaseWidget
 
 	// locker sync.Mutex
-	img        *canvas.Image
 	metrics    []string
-	values     [][]float64
-	timeStamps []string
+	options    charts.Options
+	series     []logparser.Series
+	timeStamps []time.Time
+
+	// selection holds the cursor and the time range shown, listenerID is the ID of the
+	// chart listener of selection
+	selection  *TimeSelection
+	listenerID int
+	// hovered is set while the mouse is over the chart, at position mouse
+	hovered bool
+	mouse   fyne.Position
+	// plot is the plot area of the last drawing, to map positions to times
+	plot plotArea
+}
+
+// plotArea is where the lines are drawn and the time range it shows
+type plotArea struct {
+	left, right, top, bottom float32
+	from, to                 time.Time
+}
+
+// timeAt returns the time shown at x
+func (p plotArea) timeAt(x float32) time.Time {
+	return p.from.Add(time.Duration(float64(x-p.left) / float64(p.right-p.left) * float64(p.to.Sub(p.from))))
+}
+
+// xOf returns the position of t
+func (p plotArea) xOf(t time.Time) float32 {
+	return p.left + float32(t.Sub(p.from))/float32(p.to.Sub(p.from))*(p.right-p.left)
 }
 
 // NewChartView creates a new ChartView widget. It implements fyne.Widget.
-func NewChartView(metrics []string, values [][]float64, timeStamps []string) *ChartView {
+func NewChartView(metrics []string, options charts.Options, series []logparser.Series, timeStamps []time.Time) *ChartView {
 	c := &ChartView{
-		img:        canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 1, 1))), // Needs a placeholder image
 		metrics:    metrics,
-		values:     values,
+		options:    options,
+		series:     series,
 		timeStamps: timeStamps,
 	}
 
 	c.ExtendBaseWidget(c)
+	c.SetTimeSelection(NewTimeSelection())
 	return c
 }
 
+// SetTimeSelection shares the cursor and the time range of selection
+func (c *ChartView) SetTimeSelection(selection *TimeSelection) {
+	c.releaseTimeSelection()
+	c.selection = selection
+	c.listenerID = selection.AddListener(c.Refresh)
+}
+
+// releaseTimeSelection stops following the changes of the selection, e.g. when the chart is removed
+func (c *ChartView) releaseTimeSelection() {
+	if c.selection != nil {
+		c.selection.RemoveListener(c.listenerID)
+	}
+}
+
 // Set a sane minimal size or the graph will be unreadable
 func (w *ChartView) MinSize() fyne.Size {
 	return fyne.NewSize(minSizeW, minSizeH)
 }
 
-func (c *ChartView) Resize(s fyne.Size) {
-	// Make the Chart resolution bigger than widget size for a clearer graph, fyne canvas will resize it
-	if needRerender(float32(c.img.Image.Bounds().Dx()), float32(c.img.Image.Bounds().Dy()), s.Height, s.Width) {
-		imgSize := fyne.NewSize(s.Width*1.2, s.Height*1.2)
-		c.img.Image = c.GenerateChart(imgSize)
-		c.img.FillMode = canvas.ImageFillStretch
+// CreateRenderer is a private method to Fyne which links this widget to its renderer.
+func (c *ChartView) CreateRenderer() fyne.WidgetRenderer {
+	placeholder := widget.NewLabel("No data yet")
+	placeholder.Alignment = fyne.TextAlignCenter
+	r := &chartRenderer{chart: c, placeholder: placeholder}
+	r.build(c.Size())
+	return r
+}
+
+// Re-render graphs with new data
+func (c *ChartView) RefreshData(series []logparser.Series, timeStamps []time.Time) {
+	c.series = series
+	c.timeStamps = timeStamps
+	c.Refresh()
+}
+
+// hasSamplesIn reports whether the chart has samples between from and to
+func (c *ChartView) hasSamplesIn(from, to time.Time) bool {
+	return len(c.timeStamps) > 0 && !to.Before(c.timeStamps[0]) && !from.After(c.timeStamps[len(c.timeStamps)-1])
+}
+
+// MouseIn shows the crosshair and the tooltip
+func (c *ChartView) MouseIn(ev *desktop.MouseEvent) {
+	c.MouseMoved(ev)
+}
+
+// MouseMoved moves the crosshair to the sample nearest to the mouse
+func (c *ChartView) MouseMoved(ev *desktop.MouseEvent) {
+	c.mouse = ev.Position
+	c.hovered = true
+	var cursor time.Time
+	if c.plot.to.After(c.plot.from) && ev.Position.X >= c.plot.left && ev.Position.X <= c.plot.right {
+		cursor = c.nearestTimeStamp(c.plot.timeAt(ev.Position.X))
+	}
+	if cursor.Equal(c.selection.Cursor()) {
+		// Only the horizontal line of the crosshair moved
+		c.Refresh()
+		return
+	}
+	c.selection.SetCursor(cursor)
+}
+
+// MouseOut hides the crosshair and the tooltip
+func (c *ChartView) MouseOut() {
+	c.hovered = false
+	c.selection.SetCursor(time.Time{})
+	c.Refresh()
+}
+
+// Scrolled zooms the time axis around the mouse
+func (c *ChartView) Scrolled(ev *fyne.ScrollEvent) {
+	if !c.plot.to.After(c.plot.from) || ev.Scrolled.DY == 0 {
+		return
+	}
+	factor := zoomFactor
+	if ev.Scrolled.DY < 0 {
+		factor = 1 / zoomFactor
+	}
+	// The plot range of bar charts is padded, the zoom applies to the samples range
+	viewFrom, viewTo := c.timeRange()
+	at := c.plot.timeAt(min(max(ev.Position.X, c.plot.left), c.plot.right))
+	if at.Before(viewFrom) {
+		at = viewFrom
+	} else if at.After(viewTo) {
+		at = viewTo
+	}
+	from := at.Add(-time.Duration(float64(at.Sub(viewFrom)) * factor))
+	to := at.Add(time.Duration(float64(viewTo.Sub(at)) * factor))
+	if to.Sub(from) < minZoomSpan {
+		return
+	}
+	c.setView(from, to)
+}
+
+// Dragged pans the time axis
+func (c *ChartView) Dragged(ev *fyne.DragEvent) {
+	if !c.plot.to.After(c.plot.from) {
+		return
+	}
+	shift := -time.Duration(float64(ev.Dragged.DX) / float64(c.plot.right-c.plot.left) * float64(c.plot.to.Sub(c.plot.from)))
+	from, to := c.timeRange()
+	c.setView(from.Add(shift), to.Add(shift))
+}
+
+func (c *ChartView) DragEnd() {
+}
+
+// DoubleTapped resets the zoom
+func (c *ChartView) DoubleTapped(_ *fyne.PointEvent) {
+	c.setView(time.Time{}, time.Time{})
+}
+
+// setView shows the time range from to, kept within the samples. Zero times, or a range
+// covering all the samples, show all the samples.
+func (c *ChartView) setView(from, to time.Time) {
+	if len(c.timeStamps) == 0 || from.IsZero() {
+		c.selection.SetRange(time.Time{}, time.Time{})
+		return
+	}
+	first, last := c.timeStamps[0], c.timeStamps[len(c.timeStamps)-1]
+	span := to.Sub(from)
+	if span >= last.Sub(first) {
+		c.selection.SetRange(time.Time{}, time.Time{})
+		return
+	}
+	if from.Before(first) {
+		from, to = first, first.Add(span)
+	}
+	if to.After(last) {
+		from, to = last.Add(-span), last
+	}
+	c.selection.SetRange(from, to)
+}
+
+// timeRange returns the time range shown
+func (c *ChartView) timeRange() (time.Time, time.Time) {
+	if from, to := c.selection.Range(); !from.IsZero() {
+		return from, to
+	}
+	from, to := c.timeStamps[0], c.timeStamps[len(c.timeStamps)-1]
+	if !to.After(from) {
+		from, to = from.Add(-time.Minute/2), to.Add(time.Minute/2)
 	}
-	c.BaseWidget.Resize(s)
+	return from, to
+}
+
+// nearestTimeStamp returns the sample time nearest to t
+func (c *ChartView) nearestTimeStamp(t time.Time) time.Time {
+	i, _ := slices.BinarySearchFunc(c.timeStamps, t, time.Time.Compare)
+	switch {
+	case i == 0:
+		return c.timeStamps[0]
+	case i == len(c.timeStamps):
+		return c.timeStamps[i-1]
+	case t.Sub(c.timeStamps[i-1]) < c.timeStamps[i].Sub(t):
+		return c.timeStamps[i-1]
+	}
+	return c.timeStamps[i]
+}
+
+// hasValues reports whether the chart has at least one value to draw
+func (c *ChartView) hasValues() bool {
+	for _, s := range c.series {
+		for _, v := range s.Values {
+			if !math.IsNaN(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// chartRenderer rebuilds the lines and texts of the chart at every layout or refresh,
+// a chart is a few hundred segments so this is cheap
+type chartRenderer struct {
+	chart       *ChartView
+	placeholder *widget.Label
+	objects     []fyne.CanvasObject
+}
+
+func (r *chartRenderer) Layout(size fyne.Size) {
+	r.build(size)
+}
+
+func (r *chartRenderer) MinSize() fyne.Size {
+	return r.chart.MinSize()
+}
+
+func (r *chartRenderer) Refresh() {
+	r.build(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *chartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *chartRenderer) Destroy() {
 }
 
-// GenerateChart generate a chart image used by this widget.
-func (c *ChartView) GenerateChart(s fyne.Size) image.Image {
-	Width, Height := s.Width, s.Height
+// build creates the objects drawing the chart at size
+func (r *chartRenderer) build(size fyne.Size) {
+	c := r.chart
+	r.objects = nil
+	c.plot = plotArea{}
+	if size.Width < 1 || size.Height < 1 {
+		return
+	}
+	if !c.hasValues() || len(c.timeStamps) == 0 {
+		r.placeholder.Resize(r.placeholder.MinSize().Max(fyne.NewSize(size.Width, 0)))
+		r.placeholder.Move(fyne.NewPos(0, (size.Height-r.placeholder.MinSize().Height)/2))
+		r.objects = []fyne.CanvasObject{r.placeholder}
+		return
+	}
+
+	textSize := theme.CaptionTextSize()
+	lineHeight := fyne.MeasureText("0", textSize, fyne.TextStyle{}).Height
+	foreground := theme.Color(theme.ColorNameForeground)
+	gridColor := theme.Color(theme.ColorNameSeparator)
+
+	// Legend, wrapped on several rows if needed
+	x, y := float32(chartPadding), float32(chartPadding)
+	for i, s := range c.series {
+		width := fyne.MeasureText(s.Label, textSize, fyne.TextStyle{}).Width + 16 + 12
+		if x+width > size.Width-chartPadding && x > chartPadding {
+			x, y = chartPadding, y+lineHeight
+		}
+		marker := canvas.NewRectangle(palette[i%len(palette)])
+		marker.Resize(fyne.NewSize(12, 3))
+		marker.Move(fyne.NewPos(x, y+lineHeight/2-1))
+		r.objects = append(r.objects, marker, r.text(s.Label, fyne.NewPos(x+16, y), textSize, foreground))
+		x += width
+	}
+	top := y + lineHeight + chartPadding
+	bottom := size.Height - chartPadding - lineHeight
+
+	// Samples in the time range shown, with one more on each side for the lines
+	// entering and leaving the plot
+	from, to := c.timeRange()
+	first, _ := slices.BinarySearchFunc(c.timeStamps, from, time.Time.Compare)
+	last, _ := slices.BinarySearchFunc(c.timeStamps, to, func(t time.Time, to time.Time) int {
+		if t.After(to) {
+			return 1
+		}
+		return -1
+	})
+	shown := max(last-first, 1)
+	if c.options.Type == charts.Bar {
+		// Half a bar of room on each side for the first and last bars
+		pad := to.Sub(from) / time.Duration(2*shown)
+		from, to = from.Add(-pad), to.Add(pad)
+	}
+	first, last = max(first-1, 0), min(last+1, len(c.timeStamps))
 
-	// Handle zero or very small dimensions
-	if s.Width <= 1 || s.Height <= 1 {
-		Width = 600
-		Height = 300 // sensible default 2:1 ratio
+	// Y axis on the values shown, zero based for positive values, unless fixed by the options
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range c.series {
+		for _, v := range s.Values[first:min(last, len(s.Values))] {
+			if !math.IsNaN(v) {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+		}
+	}
+	if math.IsInf(lo, 0) {
+		lo, hi = 0, 1
+	}
+	lo = min(lo, 0)
+	if c.options.YMin != nil {
+		lo = *c.options.YMin
+	}
+	if c.options.YMax != nil {
+		hi = *c.options.YMax
+	}
+	ticks := charts.ValueTicks(lo, hi, max(2, int((bottom-top)/40)))
+	yMin, yMax := ticks[0], ticks[len(ticks)-1]
+	if c.options.YMin != nil || c.options.YMax != nil {
+		// Fixed bounds are kept as given, values out of them are drawn on the border
+		yMin, yMax = lo, hi
+		if yMax <= yMin {
+			// e.g. a fixed min above all the values, the span must not be lost in the rounding of yMin
+			yMax = yMin + max(1e-9, math.Abs(yMin)*1e-9)
+		}
+		ticks = slices.DeleteFunc(ticks, func(tick float64) bool { return tick < yMin || tick > yMax })
+	}
+	labels := make([]string, len(ticks))
+	var labelWidth float32
+	for i, tick := range ticks {
+		labels[i] = charts.FormatValue(tick, c.options.Unit)
+		labelWidth = max(labelWidth, fyne.MeasureText(labels[i], textSize, fyne.TextStyle{}).Width)
+	}
+	left := chartPadding + labelWidth + 4
+	right := size.Width - chartPadding
+	yOf := func(v float64) float32 {
+		v = min(max(v, yMin), yMax)
+		return bottom - float32((v-yMin)/(yMax-yMin))*(bottom-top)
+	}
+	for i, tick := range ticks {
+		ty := yOf(tick)
+		r.objects = append(r.objects, newLine(fyne.NewPos(left, ty), fyne.NewPos(right, ty), gridColor, 1))
+		labelSize := fyne.MeasureText(labels[i], textSize, fyne.TextStyle{})
+		r.objects = append(r.objects, r.text(labels[i], fyne.NewPos(left-4-labelSize.Width, ty-labelSize.Height/2), textSize, foreground))
+	}
+
+	// X axis on the time of the samples, gaps in time are gaps in the chart
+	c.plot = plotArea{left: left, right: right, top: top, bottom: bottom, from: from, to: to}
+	r.objects = append(r.objects, newLine(fyne.NewPos(left, bottom), fyne.NewPos(right, bottom), foreground, 1))
+	for _, tick := range charts.TimeTicks(from, to, c.options.Origin, max(1, int((right-left)/chartTickSpacing))) {
+		tx := c.plot.xOf(tick)
+		label := charts.FormatTime(tick, from, to, c.options.Origin)
+		labelSize := fyne.MeasureText(label, textSize, fyne.TextStyle{})
+		r.objects = append(r.objects,
+			newLine(fyne.NewPos(tx, bottom), fyne.NewPos(tx, bottom+3), foreground, 1),
+			r.text(label, fyne.NewPos(tx-labelSize.Width/2, bottom+2), textSize, foreground))
+	}
+
+	if c.options.Type == charts.Bar {
+		r.buildBars(first, last, shown, yOf)
 	} else {
-		// Maintain aspect ratio while enforcing minimum width
-		if Width < 600 {
-			ratio := float32(s.Width) / float32(s.Height)
-			Width = 600
-			Height = Width / ratio
+		r.buildLines(first, last, yOf)
+	}
+
+	if cursor := c.selection.Cursor(); !cursor.IsZero() && !cursor.Before(from) && !cursor.After(to) {
+		r.buildCursor(cursor, textSize, lineHeight)
+	}
+}
+
+// buildLines draws the series samples from first to last as lines, or steps with the Step
+// type. Max and sum series are dashed so that they read as a band around the last and avg lines.
+func (r *chartRenderer) buildLines(first, last int, yOf func(float64) float32) {
+	c := r.chart
+	p := c.plot
+	step := c.options.Type == charts.Step
+	for i, s := range c.series {
+		seriesColor := palette[i%len(palette)]
+		dashed := s.Aggregate == logparser.Max || s.Aggregate == logparser.Sum
+		d := dasher{}
+		segment := func(p1, p2 fyne.Position) {
+			p1, p2, visible := clipSegment(p1, p2, p.left, p.right)
+			switch {
+			case !visible:
+			case dashed:
+				r.objects = append(r.objects, d.lines(p1, p2, seriesColor)...)
+			default:
+				r.objects = append(r.objects, newLine(p1, p2, seriesColor, chartStrokeWidth))
+			}
+		}
+
+		var prev *fyne.Position
+		for j := first; j < min(last, len(s.Values)); j++ {
+			v := s.Values[j]
+			if math.IsNaN(v) {
+				prev = nil
+				continue
+			}
+			pos := fyne.NewPos(p.xOf(c.timeStamps[j]), yOf(v))
+			isolated := prev == nil && (j+1 >= len(s.Values) || math.IsNaN(s.Values[j+1]))
+			if isolated && pos.X >= p.left && pos.X <= p.right {
+				dot := canvas.NewCircle(seriesColor)
+				dot.Resize(fyne.NewSize(chartStrokeWidth*2, chartStrokeWidth*2))
+				dot.Move(pos.SubtractXY(chartStrokeWidth, chartStrokeWidth))
+				r.objects = append(r.objects, dot)
+			}
+			switch {
+			case prev == nil:
+			case step:
+				corner := fyne.NewPos(pos.X, prev.Y)
+				segment(*prev, corner)
+				segment(corner, pos)
+			default:
+				segment(*prev, pos)
+			}
+			prev = &pos
+		}
+	}
+}
+
+// buildBars draws the series samples from first to last as bars from zero, the bars of
+// a sample side by side in its share of the width, shown samples share it. Max and sum
+// bars are translucent.
+func (r *chartRenderer) buildBars(first, last, shown int, yOf func(float64) float32) {
+	c := r.chart
+	p := c.plot
+	slot := (p.right - p.left) / float32(shown)
+	barWidth := max(slot*0.8/float32(len(c.series)), 1)
+	base := yOf(0)
+
+	for i, s := range c.series {
+		barColor := palette[i%len(palette)]
+		if s.Aggregate == logparser.Max || s.Aggregate == logparser.Sum {
+			barColor.A = 0x80
+		}
+		for j := first; j < min(last, len(s.Values)); j++ {
+			if math.IsNaN(s.Values[j]) {
+				continue
+			}
+			x := p.xOf(c.timeStamps[j]) - slot*0.4 + float32(i)*barWidth
+			if x < p.left || x+barWidth > p.right {
+				continue
+			}
+			y := yOf(s.Values[j])
+			bar := canvas.NewRectangle(barColor)
+			bar.Move(fyne.NewPos(x, min(y, base)))
+			bar.Resize(fyne.NewSize(barWidth, max(float32(math.Abs(float64(base-y))), 1)))
+			r.objects = append(r.objects, bar)
+		}
+	}
+}
+
+// buildCursor draws the cursor line. The hovered chart adds the horizontal line of the
+// crosshair and the tooltip with the time and the values of the sample under the mouse.
+func (r *chartRenderer) buildCursor(cursor time.Time, textSize, lineHeight float32) {
+	c := r.chart
+	p := c.plot
+	cursorColor := theme.Color(theme.ColorNameForeground)
+	cx := p.xOf(cursor)
+	r.objects = append(r.objects, newLine(fyne.NewPos(cx, p.top), fyne.NewPos(cx, p.bottom), cursorColor, 1))
+	if !c.hovered {
+		return
+	}
+	if c.mouse.Y >= p.top && c.mouse.Y <= p.bottom {
+		r.objects = append(r.objects, newLine(fyne.NewPos(p.left, c.mouse.Y), fyne.NewPos(p.right, c.mouse.Y), cursorColor, 1))
+	}
+
+	row, found := slices.BinarySearchFunc(c.timeStamps, cursor, time.Time.Compare)
+	if !found {
+		return
+	}
+	lines := []string{cursor.Local().Format("2006-01-02 15:04:05")}
+	if !c.options.Origin.IsZero() {
+		lines[0] = charts.FormatTime(cursor, p.from, p.to, c.options.Origin)
+	}
+	var colors []color.Color
+	for i, s := range c.series {
+		if row >= len(s.Values) || math.IsNaN(s.Values[row]) {
+			continue
 		}
+		lines = append(lines, fmt.Sprintf("%s: %s", s.Label, charts.FormatValue(s.Values[row], c.options.Unit)))
+		colors = append(colors, palette[i%len(palette)])
 	}
 
-	chartImageBuff := charts.Chart(c.metrics, c.values, c.timeStamps, Width, Height)
-	chartImageReader := bytes.NewReader(chartImageBuff)
-	chartImage, _, _ := image.Decode(chartImageReader)
-	return chartImage
+	var width float32
+	for _, line := range lines {
+		width = max(width, fyne.MeasureText(line, textSize, fyne.TextStyle{}).Width)
+	}
+	boxSize := fyne.NewSize(width+2*chartPadding, float32(len(lines))*lineHeight+chartPadding)
+	// Next to the cursor, on the side with more room
+	boxPos := fyne.NewPos(cx+chartPadding, p.top)
+	if cx+chartPadding+boxSize.Width > p.right {
+		boxPos.X = cx - chartPadding - boxSize.Width
+	}
+
+	box := canvas.NewRectangle(theme.Color(theme.ColorNameOverlayBackground))
+	box.StrokeColor = theme.Color(theme.ColorNameSeparator)
+	box.StrokeWidth = 1
+	box.CornerRadius = 4
+	box.Resize(boxSize)
+	box.Move(boxPos)
+	r.objects = append(r.objects, box)
+	for i, line := range lines {
+		lineColor := cursorColor
+		if i > 0 {
+			lineColor = colors[i-1]
+		}
+		r.objects = append(r.objects, r.text(line, boxPos.AddXY(chartPadding, chartPadding/2+float32(i)*lineHeight), textSize, lineColor))
+	}
 }
 
-// CreateRenderer is a private method to Fyne which links this widget to its renderer.
-func (c *ChartView) CreateRenderer() fyne.WidgetRenderer {
-	co := container.NewAdaptiveGrid(1, c.img)
-	return widget.NewSimpleRenderer(co)
+// clipSegment clips the segment from p1 to p2, sorted by x, to the x range left to right
+func clipSegment(p1, p2 fyne.Position, left, right float32) (fyne.Position, fyne.Position, bool) {
+	if p2.X < left || p1.X > right || p2.X == p1.X {
+		return p1, p2, p2.X == p1.X && p1.X >= left && p1.X <= right
+	}
+	at := func(x float32) fyne.Position {
+		return fyne.NewPos(x, p1.Y+(p2.Y-p1.Y)*(x-p1.X)/(p2.X-p1.X))
+	}
+	if p1.X < left {
+		p1 = at(left)
+	}
+	if p2.X > right {
+		p2 = at(right)
+	}
+	return p1, p2, true
 }
 
-// Re-render graphs with new data
-func (c *ChartView) RefreshData(values [][]float64, timeStamps []string) {
-	c.values = values
-	c.timeStamps = timeStamps
-	c.img.Image = c.GenerateChart(c.Size())
-	c.img.Refresh()
+func (r *chartRenderer) text(s string, pos fyne.Position, size float32, c color.Color) *canvas.Text {
+	text := canvas.NewText(s, c)
+	text.TextSize = size
+	text.Move(pos)
+	return text
+}
+
+func newLine(p1, p2 fyne.Position, c color.Color, width float32) *canvas.Line {
+	line := canvas.NewLine(c)
+	line.StrokeWidth = width
+	line.Position1 = p1
+	line.Position2 = p2
+	return line
 }
 
-// needRerender checks if chart image needs to be re-rendered for new widget size
-func needRerender(dx, dy, wdgDx, wdgDy float32) bool {
-	if wdgDx > dx || wdgDy > dy || wdgDx < (dx*0.5) || wdgDy < (dy*0.5) {
-		// fmt.Printf("Rerender: dx:%v dy:%v wx:%v wy:%v\n", dx, dy, wdgDx, wdgDy)
-		return true
+// dasher splits a polyline in dashes, keeping the dash pattern across its segments
+type dasher struct {
+	// gap is set while drawing the space between two dashes
+	gap bool
+	// done is the length already drawn of the current dash or gap
+	done float32
+}
+
+// lines returns the dashes of the segment from p1 to p2
+func (d *dasher) lines(p1, p2 fyne.Position, c color.Color) []fyne.CanvasObject {
+	dx, dy := p2.X-p1.X, p2.Y-p1.Y
+	length := float32(math.Hypot(float64(dx), float64(dy)))
+	at := func(pos float32) fyne.Position {
+		return fyne.NewPos(p1.X+dx*pos/length, p1.Y+dy*pos/length)
 	}
-	return false
+
+	var lines []fyne.CanvasObject
+	for pos := float32(0); pos < length; {
+		size := float32(chartDash)
+		if d.gap {
+			size = chartGap
+		}
+		end := min(length, pos+size-d.done)
+		if !d.gap && end > pos {
+			lines = append(lines, newLine(at(pos), at(end), c, chartStrokeWidth))
+		}
+		d.done += end - pos
+		// Rounding can leave a remainder too small to move pos
+		if d.done >= size-0.01 {
+			d.gap, d.done = !d.gap, 0
+		}
+		pos = end
+	}
+	return lines
 }
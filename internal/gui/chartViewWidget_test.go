@@ -0,0 +1,67 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package gui
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/dcvix/dcvix-stats/internal/charts"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// Charts with y axis bounds that can't be divided in ticks must still be drawn
+func TestChartViewExtremeBounds(t *testing.T) {
+	test.NewTempApp(t)
+
+	start := time.Date(2025, 9, 26, 10, 0, 0, 0, time.UTC)
+	timeStamps := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute)}
+	series := []logparser.Series{{Label: "quic_rtt_nanos", Values: []float64{1, 2, 3}}}
+
+	large, huge := 1e15, 1e17
+	for _, options := range []charts.Options{
+		{YMin: &large},
+		{YMin: &huge},
+		{YMax: &huge},
+		{YMin: &huge, Type: charts.Bar},
+	} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			w := test.NewWindow(NewChartView(nil, options, series, timeStamps))
+			w.Resize(fyne.NewSize(600, 300))
+			w.Canvas().Capture()
+			w.Close()
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("drawing a chart with y bounds %v, %v doesn't return", options.YMin, options.YMax)
+		}
+	}
+}
+
+func TestTimeSelectionRemoveListener(t *testing.T) {
+	test.NewTempApp(t)
+
+	selection := NewTimeSelection()
+	chart := NewChartView(nil, charts.Options{}, nil, nil)
+	chart.SetTimeSelection(selection)
+	if len(selection.listeners) != 1 {
+		t.Fatalf("got %d listeners, want 1", len(selection.listeners))
+	}
+
+	chart.SetTimeSelection(NewTimeSelection())
+	if len(selection.listeners) != 0 {
+		t.Errorf("the replaced selection still has %d listeners", len(selection.listeners))
+	}
+	chart.SetTimeSelection(selection)
+	chart.releaseTimeSelection()
+	if len(selection.listeners) != 0 {
+		t.Errorf("the released selection still has %d listeners", len(selection.listeners))
+	}
+}
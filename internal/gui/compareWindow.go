@@ -0,0 +1,177 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package gui
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/compare"
+	"github.com/dcvix/dcvix-stats/internal/export"
+	"github.com/dcvix/dcvix-stats/internal/globals"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// showCompareDialog asks for the log and time ranges to compare with side a, the log
+// shown by w, then opens a compare window
+func showCompareDialog(a fyne.App, w fyne.Window, cat *catalog.Catalog, sideA compare.Side, logFile string) {
+	logFileEntry := widget.NewEntry()
+	logFileEntry.SetText(logFile)
+	browseButton := widget.NewButton("Browse...", func() {
+		showOpenFileDialog(w, logFileEntry.SetText)
+	})
+
+	newTimeEntry := func() *widget.Entry {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder("2006-01-02 15:04")
+		entry.Validator = func(s string) error {
+			_, err := export.ParseTime(s)
+			return err
+		}
+		return entry
+	}
+	fromA, toA := newTimeEntry(), newTimeEntry()
+	fromB, toB := newTimeEntry(), newTimeEntry()
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("A from", fromA),
+		widget.NewFormItem("A to", toA),
+		widget.NewFormItem("B log file", container.NewBorder(nil, nil, nil, browseButton, logFileEntry)),
+		widget.NewFormItem("B from", fromB),
+		widget.NewFormItem("B to", toB),
+	}
+	items[0].HintText = "empty for the entries window before A to"
+	items[1].HintText = "empty for the end of the log"
+	items[2].HintText = "the log of this window, or another one"
+
+	form := dialog.NewForm("Compare", "Compare", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		sideA.Label = "A"
+		sideA.From, _ = export.ParseTime(fromA.Text)
+		sideA.To, _ = export.ParseTime(toA.Text)
+
+		sideB := compare.Side{Label: "B", Parser: sideA.Parser, Connection: sideA.Connection}
+		sideB.From, _ = export.ParseTime(fromB.Text)
+		sideB.To, _ = export.ParseTime(toB.Text)
+
+		// Another log gets its own parser, its connection IDs don't match the ones of A
+		if logFileEntry.Text != logFile {
+			parser, err := openParser(cat, logFileEntry.Text, sideA.Parser.EntriesQty())
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			sideB.Parser = parser
+			sideB.Connection = logparser.AllConnections
+		}
+		newCompareWindow(a, cat, sideA, sideB).Show()
+	}, w)
+	form.Resize(fyne.NewSize(480, 0))
+	form.Show()
+}
+
+// newCompareWindow returns a window overlaying the enabled graphs of sides a and b on
+// the time elapsed since the start of each side, with a summary of their differences
+func newCompareWindow(a fyne.App, cat *catalog.Catalog, sideA, sideB compare.Side) fyne.Window {
+	w := a.NewWindow(fmt.Sprintf("Compare %s and %s - %s", sideName(sideA), sideName(sideB), globals.AppName))
+	w.SetIcon(resourceIconPng)
+	w.Resize(fyne.NewSize(WinWidth*2, WinHeigh*2))
+	prefs := a.Preferences()
+
+	graphContainer := container.NewAdaptiveGrid(2)
+	selection := NewTimeSelection()
+	var metrics []string
+	for _, graph := range slices.Concat(cat.Graphs, userGraphs(prefs)) {
+		if !prefs.BoolWithFallback(graph.Name, graph.Enabled) {
+			continue
+		}
+		series, timeStamps, origin := compare.Series(sideA, sideB, graph.Metrics)
+		options := graphOptions(cat, graph)
+		options.Origin = origin
+		chartView := NewChartView(graph.Metrics, options, series, timeStamps)
+		chartView.SetTimeSelection(selection)
+		graphContainer.Add(chartView)
+		metrics = append(metrics, graph.Metrics...)
+	}
+
+	// The graphs fit the window like in the main window, a scroll container would take
+	// the mouse wheel used to zoom the charts
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Graphs", graphContainer),
+		container.NewTabItem("Summary", newDiffTable(compare.Summarize(sideA, sideB, metrics))),
+	)
+	w.SetContent(tabs)
+
+	w.SetOnClosed(func() {
+		if sideB.Parser != sideA.Parser {
+			sideB.Parser.Close()
+		}
+	})
+	return w
+}
+
+// sideName describes the log and time range of a side
+func sideName(side compare.Side) string {
+	from, to := side.Range()
+	return fmt.Sprintf("%s %s → %s", filepath.Base(side.Parser.Source().Name()),
+		from.Local().Format("2006-01-02 15:04"), to.Local().Format("2006-01-02 15:04"))
+}
+
+// newDiffTable returns a table of the avg and p95 of every series on both sides
+func newDiffTable(diffs []compare.Diff) *widget.Table {
+	header := []string{"Metric", "Avg A", "Avg B", "Avg change", "P95 A", "P95 B", "P95 change"}
+	cell := func(row, col int) string {
+		if row == 0 {
+			return header[col]
+		}
+		d := diffs[row-1]
+		return []string{
+			d.Name,
+			formatNumber(d.A.Avg), formatNumber(d.B.Avg), formatChange(d.AvgChange()),
+			formatNumber(d.A.P95), formatNumber(d.B.P95), formatChange(d.P95Change()),
+		}[col]
+	}
+
+	table := widget.NewTable(
+		func() (int, int) { return len(diffs) + 1, len(header) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			label.TextStyle.Bold = id.Row == 0
+			label.SetText(cell(id.Row, id.Col))
+		},
+	)
+	table.SetColumnWidth(0, 220)
+	for col := 1; col < len(header); col++ {
+		table.SetColumnWidth(col, 110)
+	}
+	return table
+}
+
+// formatNumber formats values with at most two decimals, a dash for missing values
+func formatNumber(f float64) string {
+	if math.IsNaN(f) {
+		return "-"
+	}
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// formatChange formats a relative change in percent
+func formatChange(f float64) string {
+	if math.IsNaN(f) {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", f)
+}
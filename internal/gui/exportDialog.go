@@ -0,0 +1,39 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package gui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"github.com/dcvix/dcvix-stats/internal/export"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// showExportDialog asks for a file and writes to it the entries of parser selected by sel,
+// as JSON if the file name ends in .json, as CSV otherwise
+func showExportDialog(w fyne.Window, parser *logparser.LogParser, sel export.Selection) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if writer == nil {
+			return // cancelled
+		}
+		defer writer.Close()
+
+		format := export.CSV
+		if strings.EqualFold(writer.URI().Extension(), ".json") {
+			format = export.JSON
+		}
+		if err := export.Write(writer, format, export.Entries(parser, sel)); err != nil {
+			dialog.ShowError(err, w)
+		}
+	}, w)
+	saveDialog.SetFileName("dcvix-stats.csv")
+	saveDialog.Show()
+}
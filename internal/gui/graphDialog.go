@@ -0,0 +1,159 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/charts"
+)
+
+// prefUserGraphs holds the graphs created with the New graph dialog, as a TOML document
+const prefUserGraphs = "UserGraphs"
+
+// userGraphPrefix is prepended to the title of a user graph to name it
+const userGraphPrefix = "User."
+
+// userGraphs returns the graphs created by the user, none if the saved ones can't be read
+func userGraphs(prefs fyne.Preferences) []catalog.Graph {
+	data := prefs.String(prefUserGraphs)
+	if data == "" {
+		return nil
+	}
+	graphs, err := catalog.DecodeGraphs(data)
+	if err != nil {
+		fyne.LogError("Could not read the user graphs", err)
+		return nil
+	}
+	return graphs
+}
+
+// saveUserGraphs persists the graphs created by the user
+func saveUserGraphs(prefs fyne.Preferences, graphs []catalog.Graph) {
+	data, err := catalog.EncodeGraphs(graphs)
+	if err != nil {
+		fyne.LogError("Could not save the user graphs", err)
+		return
+	}
+	prefs.SetString(prefUserGraphs, data)
+}
+
+// graphOptions returns the chart options of a graph
+func graphOptions(cat *catalog.Catalog, g catalog.Graph) charts.Options {
+	return charts.Options{
+		Unit: cat.GraphUnit(g),
+		Type: charts.ChartType(g.Type),
+		YMin: g.YMin,
+		YMax: g.YMax,
+	}
+}
+
+// showNewGraphDialog asks for the definition of a new graph plotting some of metrics, each one
+// selectable as its last, avg, max or sum value. exists reports whether a graph title is already
+// used, add is called with the confirmed graph.
+func showNewGraphDialog(w fyne.Window, metrics []string, exists func(title string) bool, add func(catalog.Graph)) {
+	titleEntry := widget.NewEntry()
+	titleEntry.Validator = func(s string) error {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return errors.New("the title is required")
+		}
+		if exists(s) {
+			return errors.New("a graph with this title already exists")
+		}
+		return nil
+	}
+
+	var options []string
+	for _, metric := range metrics {
+		for _, suffix := range []string{"", "_avg", "_max", "_sum"} {
+			options = append(options, metric+suffix)
+		}
+	}
+	metricsCheck := widget.NewCheckGroup(options, nil)
+	metricsScroll := container.NewVScroll(metricsCheck)
+	metricsScroll.SetMinSize(fyne.NewSize(0, 200))
+
+	typeSelect := widget.NewSelect(catalog.GraphTypes, nil)
+	typeSelect.SetSelected(catalog.GraphTypes[0])
+
+	unitEntry := widget.NewEntry()
+	unitEntry.SetPlaceHolder("automatic")
+	yMinEntry := newFloatEntry()
+	yMaxEntry := newFloatEntry()
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Title", titleEntry),
+		widget.NewFormItem("Metrics", metricsScroll),
+		widget.NewFormItem("Type", typeSelect),
+		widget.NewFormItem("Unit", unitEntry),
+		widget.NewFormItem("Y min", yMinEntry),
+		widget.NewFormItem("Y max", yMaxEntry),
+	}
+	items[1].HintText = "no suffix plots the last value"
+	items[5].HintText = "leave the bounds empty to fit the values"
+
+	form := dialog.NewForm("New graph", "Add", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		title := strings.TrimSpace(titleEntry.Text)
+		graph := catalog.Graph{
+			Name:    userGraphPrefix + title,
+			Title:   title,
+			Metrics: metricsCheck.Selected,
+			Unit:    strings.TrimSpace(unitEntry.Text),
+			Enabled: true,
+			Type:    typeSelect.Selected,
+			YMin:    parseFloat(yMinEntry.Text),
+			YMax:    parseFloat(yMaxEntry.Text),
+		}
+		if len(graph.Metrics) == 0 {
+			dialog.ShowError(errors.New("select at least one metric"), w)
+			return
+		}
+		if graph.YMin != nil && graph.YMax != nil && *graph.YMin >= *graph.YMax {
+			dialog.ShowError(errors.New("the Y min must be lower than the Y max"), w)
+			return
+		}
+		add(graph)
+	}, w)
+	form.Resize(fyne.NewSize(480, 0))
+	form.Show()
+}
+
+// newFloatEntry returns an entry accepting a number or nothing
+func newFloatEntry() *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("automatic")
+	entry.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		if f := parseFloat(s); f == nil || math.Abs(*f) > catalog.MaxAxisBound {
+			return fmt.Errorf("a number between -%g and %g is required", catalog.MaxAxisBound, catalog.MaxAxisBound)
+		}
+		return nil
+	}
+	return entry
+}
+
+// parseFloat returns the number in s, nil if s is empty or not a number
+func parseFloat(s string) *float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	return &f
+}
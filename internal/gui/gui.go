@@ -6,26 +6,47 @@
 package gui
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"net/url"
-	"os"
+	"slices"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/dcvix/dcvix-stats/internal/catalog"
+	"github.com/dcvix/dcvix-stats/internal/charts"
+	"github.com/dcvix/dcvix-stats/internal/compare"
+	"github.com/dcvix/dcvix-stats/internal/export"
 	"github.com/dcvix/dcvix-stats/internal/globals"
+	"github.com/dcvix/dcvix-stats/internal/logger"
 	"github.com/dcvix/dcvix-stats/internal/logparser"
 	"github.com/dcvix/dcvix-stats/internal/version"
+	"github.com/dcvix/dcvix-stats/internal/watcher"
 )
 
 const WinWidth = 512
 const WinHeigh = 384
 
-func NewMainWindow(a fyne.App) fyne.Window {
+// Delays between the retries of a failed log read, doubled at every failure
+const minRetryDelay = 2 * time.Second
+const maxRetryDelay = time.Minute
 
-	parser := logparser.NewLogParser(globals.LogFile)
+// NewMainWindow returns a window showing the graphs described by cat for the log of settings.
+// Every window owns its parser and settings, several windows can show different logs.
+func NewMainWindow(a fyne.App, cat *catalog.Catalog, settings Settings) (fyne.Window, error) {
+
+	source, err := logparser.OpenSource(settings.LogFile, globals.ReadRotatedLogs)
+	if err != nil {
+		return nil, err
+	}
+	parser := logparser.NewLogParser(source, cat.MetricNames())
+	parser.SetEntriesQty(settings.LogEntriesQty)
 
 	w := a.NewWindow(globals.AppName)
 
@@ -85,63 +106,41 @@ func NewMainWindow(a fyne.App) fyne.Window {
 
 	type graphConfig struct {
 		name             string
+		title            string
 		metrics          []string
+		options          charts.Options
 		chartView        *ChartView
 		menuItem         *fyne.MenuItem
 		enabledByDefault bool
 	}
 
-	graphConfigs := []*graphConfig{
-		{
-			name:             "QUICLostPktsGraph",
-			metrics:          []string{"quic_lost_packets", "quic_lost_packets_avg"},
-			enabledByDefault: prefs.BoolWithFallback("QUICLostPktsGraph", true),
-		},
-		{
-			name:             "QUICSentRecvPktsGraph",
-			metrics:          []string{"quic_sent_packets", "quic_sent_packets_avg", "quic_recv_packets", "quic_recv_packets_avg"},
-			enabledByDefault: prefs.BoolWithFallback("QUICSentRecvPktsGraph", true),
-		},
-		{
-			name:             "QUICRttNanos",
-			metrics:          []string{"quic_rtt_nanos", "quic_rtt_nanos_avg"},
-			enabledByDefault: prefs.BoolWithFallback("QUICRttNanos", true),
-		},
-		{
-			name:             "QUICCwndSize",
-			metrics:          []string{"quic_cwnd_size", "quic_cwnd_size_avg"},
-			enabledByDefault: prefs.BoolWithFallback("QUICCwndSize", true),
-		},
-		{
-			name:             "QUICDeliveryRate",
-			metrics:          []string{"quic_delivery_rate", "quic_delivery_rate_avg"},
-			enabledByDefault: prefs.BoolWithFallback("QUICDeliveryRate", false),
-		},
-		{
-			name:             "DGrams",
-			metrics:          []string{"dgram_sent", "dgram_sent_avg", "dgram_recv", "dgram_recv_avg"},
-			enabledByDefault: prefs.BoolWithFallback("DGrams", false),
-		},
-		{
-			name:             "StreamsGraph",
-			metrics:          []string{"stream_sent", "stream_sent_avg", "stream_recv", "stream_recv_avg"},
-			enabledByDefault: prefs.BoolWithFallback("StreamsGraph", false),
-		},
-		{
-			name:             "ActiveStreamsGraph",
-			metrics:          []string{"active_streams", "active_streams_avg"},
-			enabledByDefault: prefs.BoolWithFallback("ActiveStreamsGraph", false),
-		},
+	newGraphConfig := func(graph catalog.Graph) *graphConfig {
+		return &graphConfig{
+			name:             graph.Name,
+			title:            graph.Label(),
+			metrics:          graph.Metrics,
+			options:          graphOptions(cat, graph),
+			enabledByDefault: prefs.BoolWithFallback(graph.Name, graph.Enabled),
+		}
+	}
+
+	graphConfigs := make([]*graphConfig, 0, len(cat.Graphs))
+	for _, graph := range cat.Graphs {
+		graphConfigs = append(graphConfigs, newGraphConfig(graph))
 	}
 
 	showMenuItems := make([]*fyne.MenuItem, 0, len(graphConfigs))
-	graphContainers := make([]fyne.CanvasObject, 0, len(graphConfigs))
+	graphContainer := container.NewAdaptiveGrid(2)
+	// The graphs share their cursor and zoom
+	selection := NewTimeSelection()
 
-	for _, config := range graphConfigs {
-		config.chartView = NewChartView(config.metrics, nil, nil)
-		graphContainers = append(graphContainers, config.chartView)
+	// newGraph creates the chart of config and returns its Show menu item
+	newGraph := func(config *graphConfig) *fyne.MenuItem {
+		config.chartView = NewChartView(config.metrics, config.options, nil, nil)
+		config.chartView.SetTimeSelection(selection)
+		graphContainer.Add(config.chartView)
 
-		config.menuItem = fyne.NewMenuItem(config.name, func() {
+		config.menuItem = fyne.NewMenuItem(config.title, func() {
 			config.menuItem.Checked = !config.menuItem.Checked
 			if config.menuItem.Checked {
 				config.chartView.Show()
@@ -156,53 +155,237 @@ func NewMainWindow(a fyne.App) fyne.Window {
 		if !config.menuItem.Checked {
 			config.chartView.Hide()
 		}
-		showMenuItems = append(showMenuItems, config.menuItem)
+		return config.menuItem
+	}
+
+	for _, config := range graphConfigs {
+		showMenuItems = append(showMenuItems, newGraph(config))
+	}
+
+	// Graphs created with Show > New graph, listed after the catalog ones
+	graphs := userGraphs(prefs)
+	userMenuItems := make([]*fyne.MenuItem, 0, len(graphs))
+	for _, graph := range graphs {
+		config := newGraphConfig(graph)
+		graphConfigs = append(graphConfigs, config)
+		userMenuItems = append(userMenuItems, newGraph(config))
+	}
+
+	// Redraw graphs with the samples of the selected connection
+	selectedConnection := logparser.AllConnections
+	redraw := func() {
+		for _, config := range graphConfigs {
+			series, timeStamps := parser.GetEntriesByMetricList(config.metrics, selectedConnection)
+			config.chartView.RefreshData(series, timeStamps)
+		}
+		// A zoom outside of the samples of every graph, e.g. of another log, is reset
+		if from, to := selection.Range(); !from.IsZero() && !slices.ContainsFunc(graphConfigs, func(config *graphConfig) bool {
+			return config.chartView.hasSamplesIn(from, to)
+		}) {
+			selection.SetRange(time.Time{}, time.Time{})
+		}
+	}
+
+	// Connection selector, rebuilt whenever new connections show up in the log
+	connectionMenu := fyne.NewMenu("Connection")
+	var shownConnections []int
+	var updateConnectionMenu func(force bool)
+	updateConnectionMenu = func(force bool) {
+		connections := parser.Connections()
+		if !force && slices.Equal(connections, shownConnections) {
+			return
+		}
+		shownConnections = connections
+
+		var items []*fyne.MenuItem
+		addItem := func(label string, connection int) {
+			item := fyne.NewMenuItem(label, func() {
+				selectedConnection = connection
+				redraw()
+				updateConnectionMenu(true)
+			})
+			item.Checked = selectedConnection == connection
+			items = append(items, item)
+		}
+		addItem("All connections", logparser.AllConnections)
+		items = append(items, fyne.NewMenuItemSeparator())
+		for _, connection := range connections {
+			addItem(fmt.Sprintf("Connection %d", connection), connection)
+		}
+		connectionMenu.Items = items
+		if mainMenu != nil {
+			w.SetMainMenu(mainMenu)
+		}
+	}
+	updateConnectionMenu(true)
+
+	// Metrics found in the log but not in the catalog are listed under Show > Other,
+	// each one gets its own graph once found
+	parser.SetDiscovery(globals.DiscoverMetrics)
+	showMenu := fyne.NewMenu("Show")
+	otherMenu := fyne.NewMenu("Other")
+	otherMenuItem := fyne.NewMenuItem("Other", nil)
+	otherMenuItem.ChildMenu = otherMenu
+	newGraphItem := fyne.NewMenuItem("New graph...", nil)
+	deleteMenu := fyne.NewMenu("Delete graph")
+	deleteMenuItem := fyne.NewMenuItem("Delete graph", nil)
+	deleteMenuItem.ChildMenu = deleteMenu
+
+	// updateShowMenu lists the catalog graphs, the user graphs and the discovered metrics
+	var deleteGraph func(name string)
+	updateShowMenu := func() {
+		deleteMenu.Items = nil
+		for _, item := range userMenuItems {
+			deleteMenu.Items = append(deleteMenu.Items, fyne.NewMenuItem(item.Label, func() {
+				dialog.ShowConfirm("Delete graph", fmt.Sprintf("Delete the graph %q?", item.Label), func(confirmed bool) {
+					if confirmed {
+						deleteGraph(userGraphPrefix + item.Label)
+					}
+				}, w)
+			}))
+		}
+		deleteMenuItem.Disabled = len(deleteMenu.Items) == 0
+
+		showMenu.Items = slices.Concat(showMenuItems, userMenuItems,
+			[]*fyne.MenuItem{fyne.NewMenuItemSeparator(), newGraphItem, deleteMenuItem})
+		if len(otherMenu.Items) > 0 {
+			showMenu.Items = append(showMenu.Items, fyne.NewMenuItemSeparator(), otherMenuItem)
+		}
+		if mainMenu != nil {
+			w.SetMainMenu(mainMenu)
+		}
+	}
+	updateShowMenu()
+
+	// Add a graph created with the New graph dialog, shown at once and saved in the preferences
+	newGraphItem.Action = func() {
+		metrics := slices.Concat(cat.MetricNames(), parser.DiscoveredMetrics())
+		exists := func(title string) bool {
+			return slices.ContainsFunc(graphConfigs, func(config *graphConfig) bool {
+				return config.title == title || config.name == userGraphPrefix+title
+			})
+		}
+		showNewGraphDialog(w, metrics, exists, func(graph catalog.Graph) {
+			saveUserGraphs(prefs, append(userGraphs(prefs), graph))
+			prefs.SetBool(graph.Name, true)
+			config := newGraphConfig(graph)
+			graphConfigs = append(graphConfigs, config)
+			userMenuItems = append(userMenuItems, newGraph(config))
+			series, timeStamps := parser.GetEntriesByMetricList(config.metrics, selectedConnection)
+			config.chartView.RefreshData(series, timeStamps)
+			updateShowMenu()
+		})
 	}
 
+	// Remove a graph from the window, its chart stops following the time selection
+	removeGraph := func(config *graphConfig) {
+		graphContainer.Remove(config.chartView)
+		config.chartView.releaseTimeSelection()
+		graphConfigs = slices.DeleteFunc(graphConfigs, func(c *graphConfig) bool { return c == config })
+	}
+
+	// Remove a user graph from the window and the preferences
+	deleteGraph = func(name string) {
+		i := slices.IndexFunc(graphConfigs, func(config *graphConfig) bool { return config.name == name })
+		if i < 0 {
+			return
+		}
+		config := graphConfigs[i]
+		removeGraph(config)
+		userMenuItems = slices.DeleteFunc(userMenuItems, func(item *fyne.MenuItem) bool { return item == config.menuItem })
+		saveUserGraphs(prefs, slices.DeleteFunc(userGraphs(prefs), func(g catalog.Graph) bool { return g.Name == name }))
+		prefs.RemoveValue(name)
+		updateShowMenu()
+	}
+
+	// The Other graphs follow the metrics discovered in the current log, those of a
+	// previous log are removed when another log is opened
+	otherGraphs := make(map[string]*graphConfig)
+	updateOtherMenu := func() {
+		discovered := parser.DiscoveredMetrics()
+		if slices.Equal(discovered, slices.Sorted(maps.Keys(otherGraphs))) {
+			return
+		}
+		for metric, config := range otherGraphs {
+			if !slices.Contains(discovered, metric) {
+				removeGraph(config)
+				delete(otherGraphs, metric)
+			}
+		}
+		for _, metric := range discovered {
+			if otherGraphs[metric] != nil {
+				continue
+			}
+			name := "Other." + metric
+			config := &graphConfig{
+				name:             name,
+				title:            metric,
+				metrics:          []string{metric, metric + "_avg"},
+				enabledByDefault: prefs.BoolWithFallback(name, false),
+			}
+			graphConfigs = append(graphConfigs, config)
+			newGraph(config)
+			otherGraphs[metric] = config
+		}
+		otherMenu.Items = nil
+		for _, metric := range discovered {
+			otherMenu.Items = append(otherMenu.Items, otherGraphs[metric].menuItem)
+		}
+		updateShowMenu()
+	}
+
+	// Read errors are shown in a banner and retried with an increasing delay, the window
+	// waits for a log file not created yet
+	var refresh func()
+	var retryTimer *time.Timer
+	retryDelay := minRetryDelay
+	banner := newStatusBanner(func() { refresh() })
+
 	// Reload log file and redraw graphs
-	refresh := func() {
+	refresh = func() {
+		if retryTimer != nil {
+			retryTimer.Stop()
+			retryTimer = nil
+		}
+
 		err := parser.ReadLogFile()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error, could not read log file: %s\n", globals.LogFile)
-			os.Exit(1)
+			message := fmt.Sprintf("Could not read the log %s: %v, retrying in %v", settings.LogFile, err, retryDelay)
+			if errors.Is(err, fs.ErrNotExist) {
+				message = fmt.Sprintf("Waiting for the log %s to be created...", settings.LogFile)
+			}
+			logger.LogVerbosef("%s\n", message)
+			banner.showMessage(message)
+			retryTimer = time.AfterFunc(retryDelay, func() { fyne.Do(refresh) })
+			retryDelay = min(retryDelay*2, maxRetryDelay)
+			return
 		}
+		retryDelay = minRetryDelay
+		banner.Hide()
 
-		for _, config := range graphConfigs {
-			values, timeStamps := parser.GetEntriesByMetricList(config.metrics)
-			config.chartView.RefreshData(values, timeStamps)
-		}
+		updateConnectionMenu(false)
+		updateOtherMenu()
+		redraw()
+		w.SetTitle(windowTitle(parser))
 	}
 	refresh()
 
-	// Auto refresh ticker
-	var autoRefreshTicker *time.Ticker
-	var autoRefreshDone chan bool
+	// Auto refresh, when the log changes
+	var logWatcher *watcher.Watcher
 
 	startAutoRefresh := func() {
-		if autoRefreshTicker != nil {
+		if logWatcher != nil {
 			return
 		}
-		autoRefreshTicker = time.NewTicker(time.Duration(globals.RefreshInterval) * time.Second)
-		autoRefreshDone = make(chan bool)
-
-		go func() {
-			for {
-				select {
-				case <-autoRefreshTicker.C:
-					fyne.Do(func() { refresh() })
-				case <-autoRefreshDone:
-					return
-				}
-			}
-		}()
+		logWatcher = watcher.New(parser.Source(), time.Duration(settings.RefreshInterval)*time.Second, func() {
+			fyne.Do(func() { refresh() })
+		})
 	}
 
 	stopAutoRefresh := func() {
-		if autoRefreshTicker != nil {
-			autoRefreshTicker.Stop()
-			autoRefreshDone <- true
-			close(autoRefreshDone)
-			autoRefreshTicker = nil
+		if logWatcher != nil {
+			logWatcher.Close()
+			logWatcher = nil
 		}
 	}
 
@@ -220,30 +403,201 @@ func NewMainWindow(a fyne.App) fyne.Window {
 		w.SetMainMenu(mainMenu)
 	}
 
+	// File > Open Recent lists the last log files opened
+	recentMenu := fyne.NewMenu("Open Recent")
+	recentMenuItem := fyne.NewMenuItem("Open Recent", nil)
+	recentMenuItem.ChildMenu = recentMenu
+	var openLogFile func(path string)
+	updateRecentMenu := func() {
+		recentMenu.Items = nil
+		for _, path := range recentFiles(prefs) {
+			recentMenu.Items = append(recentMenu.Items, fyne.NewMenuItem(path, func() { openLogFile(path) }))
+		}
+		recentMenuItem.Disabled = len(recentMenu.Items) == 0
+		if mainMenu != nil {
+			w.SetMainMenu(mainMenu)
+		}
+	}
+	updateRecentMenu()
+
+	// openLog switches the window to another log source, the current one is kept if the
+	// new one can't be read
+	openLog := func(spec string) error {
+		newParser, err := openParser(cat, spec, settings.LogEntriesQty)
+		if err != nil {
+			return err
+		}
+
+		watching := logWatcher != nil
+		stopAutoRefresh()
+		parser.Close()
+		parser = newParser
+		settings.LogFile = spec
+		selectedConnection = logparser.AllConnections
+		updateConnectionMenu(true)
+		refresh()
+		if watching {
+			startAutoRefresh()
+		}
+		addRecentFile(prefs, spec)
+		updateRecentMenu()
+		return nil
+	}
+
+	// Open a log file, showing the errors
+	openLogFile = func(path string) {
+		if err := openLog(path); err != nil {
+			dialog.ShowError(err, w)
+		}
+	}
+
+	showOpenDialog := func() {
+		showOpenFileDialog(w, openLogFile)
+	}
+
+	// Log files dropped on the window are opened, the first one only
+	w.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
+		if len(uris) > 0 {
+			openLogFile(uris[0].Path())
+		}
+	})
+
+	// Apply the settings of the Preferences dialog without restarting
+	showSettings := func() {
+		showSettingsDialog(w, prefs, settings, func(newSettings Settings) error {
+			if newSettings.LogFile != settings.LogFile {
+				if err := openLog(newSettings.LogFile); err != nil {
+					return err
+				}
+			}
+			if newSettings.RefreshInterval != settings.RefreshInterval {
+				settings.RefreshInterval = newSettings.RefreshInterval
+				if logWatcher != nil {
+					stopAutoRefresh()
+					startAutoRefresh()
+				}
+			}
+			if newSettings.LogEntriesQty != settings.LogEntriesQty {
+				settings.LogEntriesQty = newSettings.LogEntriesQty
+				parser.SetEntriesQty(settings.LogEntriesQty)
+				redraw()
+			}
+			return nil
+		})
+	}
+
+	// New Window opens another log in its own window, with the same settings
+	showNewWindowDialog := func() {
+		showOpenFileDialog(w, func(path string) {
+			newSettings := settings
+			newSettings.LogFile = path
+			newWindow, err := NewMainWindow(a, cat, newSettings)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			addRecentFile(prefs, newSettings.LogFile)
+			updateRecentMenu()
+			newWindow.Show()
+		})
+	}
+
+	// Compare the log of this window with another log or another time range
+	showCompare := func() {
+		sideA := compare.Side{Parser: parser, Connection: selectedConnection}
+		showCompareDialog(a, w, cat, sideA, settings.LogFile)
+	}
+
 	// Window close handling
 	w.SetCloseIntercept(func() {
 		stopAutoRefresh()
+		if retryTimer != nil {
+			retryTimer.Stop()
+		}
+		parser.Close()
 		w.Close()
 	})
 
+	// Export the metrics of the visible graphs in the time window shown
+	exportData := func() {
+		sel := export.Selection{Connection: selectedConnection}
+		for _, config := range graphConfigs {
+			if config.menuItem.Checked {
+				sel.Metrics = append(sel.Metrics, config.metrics...)
+			}
+		}
+		if last := parser.LastTimestamp(); !last.IsZero() {
+			sel.From = last.Add(-time.Duration(parser.EntriesQty()) * time.Minute)
+		}
+		showExportDialog(w, parser, sel)
+	}
+
 	// Menus
 	fileMenu := fyne.NewMenu("File",
 		fyne.NewMenuItem("About", showAbout),
+		fyne.NewMenuItem("New Window...", showNewWindowDialog),
+		fyne.NewMenuItem("Open...", showOpenDialog),
+		recentMenuItem,
+		fyne.NewMenuItem("Preferences...", showSettings),
+		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Refresh", refresh),
 		autoRefreshItem,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Compare...", showCompare),
+		fyne.NewMenuItem("Export...", exportData),
 	)
 
-	showMenu := fyne.NewMenu("Show", showMenuItems...)
-	mainMenu = fyne.NewMainMenu(fileMenu, showMenu)
+	mainMenu = fyne.NewMainMenu(fileMenu, showMenu, connectionMenu)
 	w.SetMainMenu(mainMenu)
 
 	// Main container
-	w.SetContent(container.NewAdaptiveGrid(2, graphContainers...))
+	w.SetContent(container.NewBorder(banner, nil, nil, nil, graphContainer))
 
 	if prefs.BoolWithFallback("AutoRefresh", false) {
 		startAutoRefresh()
 	}
 
-	return w
+	return w, nil
+
+}
+
+// windowTitle names the log shown by a window and the DCV server version, when known
+func windowTitle(parser *logparser.LogParser) string {
+	title := fmt.Sprintf("%s - %s", parser.Source().Name(), globals.AppName)
+	if version := parser.ServerVersion(); version != "" {
+		title = fmt.Sprintf("%s (DCV %s) - %s", parser.Source().Name(), version, globals.AppName)
+	}
+	return title
+}
+
+// openParser returns a parser of spec after its first read
+func openParser(cat *catalog.Catalog, spec string, entriesQty int) (*logparser.LogParser, error) {
+	source, err := logparser.OpenSource(spec, globals.ReadRotatedLogs)
+	if err != nil {
+		return nil, err
+	}
+	parser := logparser.NewLogParser(source, cat.MetricNames())
+	parser.SetDiscovery(globals.DiscoverMetrics)
+	parser.SetEntriesQty(entriesQty)
+	if err := parser.ReadLogFile(); err != nil {
+		parser.Close()
+		return nil, err
+	}
+	return parser, nil
+}
 
+// showOpenFileDialog asks for a file and calls open with its path, unless cancelled
+func showOpenFileDialog(w fyne.Window, open func(path string)) {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if reader == nil {
+			return // cancelled
+		}
+		reader.Close()
+		open(reader.URI().Path())
+	}, w)
+	openDialog.Show()
 }
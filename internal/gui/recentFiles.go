@@ -0,0 +1,30 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package gui
+
+import (
+	"slices"
+
+	"fyne.io/fyne/v2"
+)
+
+const prefRecentFiles = "RecentFiles"
+
+// maxRecentFiles is how many log files the File > Open Recent menu lists
+const maxRecentFiles = 10
+
+// recentFiles returns the log files opened last, most recent first
+func recentFiles(prefs fyne.Preferences) []string {
+	return prefs.StringList(prefRecentFiles)
+}
+
+// addRecentFile moves path at the top of the recent files
+func addRecentFile(prefs fyne.Preferences, path string) {
+	files := slices.DeleteFunc(recentFiles(prefs), func(f string) bool { return f == path })
+	files = slices.Insert(files, 0, path)
+	if len(files) > maxRecentFiles {
+		files = files[:maxRecentFiles]
+	}
+	prefs.SetStringList(prefRecentFiles, files)
+}
@@ -0,0 +1,108 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package gui
+
+import (
+	"errors"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/dcvix/dcvix-stats/internal/globals"
+)
+
+// Preference keys of the settings, named after the matching flags
+const (
+	prefLogFile = "Settings.logfile"
+	prefEntries = "Settings.entries"
+	prefRefresh = "Settings.refresh"
+)
+
+// Settings are the options of a window, changed in the Preferences dialog
+type Settings struct {
+	LogFile         string
+	LogEntriesQty   int
+	RefreshInterval int
+}
+
+// LoadSettings sets globals to the settings saved in prefs, except the ones given
+// on the command line: flags lists the names of the flags set
+func LoadSettings(prefs fyne.Preferences, flags map[string]bool) {
+	if !flags["logfile"] {
+		globals.LogFile = prefs.StringWithFallback(prefLogFile, globals.LogFile)
+	}
+	if !flags["entries"] {
+		globals.LogEntriesQty = prefs.IntWithFallback(prefEntries, globals.LogEntriesQty)
+	}
+	if !flags["refresh"] {
+		globals.RefreshInterval = prefs.IntWithFallback(prefRefresh, globals.RefreshInterval)
+	}
+}
+
+// saveSettings persists settings in prefs
+func saveSettings(prefs fyne.Preferences, settings Settings) {
+	prefs.SetString(prefLogFile, settings.LogFile)
+	prefs.SetInt(prefEntries, settings.LogEntriesQty)
+	prefs.SetInt(prefRefresh, settings.RefreshInterval)
+}
+
+// showSettingsDialog shows the Preferences dialog filled with current, apply is called with
+// the confirmed settings which are saved only if it succeeds
+func showSettingsDialog(w fyne.Window, prefs fyne.Preferences, current Settings, apply func(Settings) error) {
+	logFileEntry := widget.NewEntry()
+	logFileEntry.SetText(current.LogFile)
+	logFileEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New("the log file is required")
+		}
+		return nil
+	}
+	browseButton := widget.NewButton("Browse...", func() {
+		showOpenFileDialog(w, logFileEntry.SetText)
+	})
+
+	entriesEntry := newIntEntry(current.LogEntriesQty)
+	refreshEntry := newIntEntry(current.RefreshInterval)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Log file", container.NewBorder(nil, nil, nil, browseButton, logFileEntry)),
+		widget.NewFormItem("Entries (minutes)", entriesEntry),
+		widget.NewFormItem("Refresh (seconds)", refreshEntry),
+	}
+	items[0].HintText = "file, glob, directory, - for stdin or syslog+udp://:514"
+	items[2].HintText = "polling interval of the auto refresh"
+
+	form := dialog.NewForm("Preferences", "Apply", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		settings := Settings{LogFile: logFileEntry.Text}
+		settings.LogEntriesQty, _ = strconv.Atoi(entriesEntry.Text)
+		settings.RefreshInterval, _ = strconv.Atoi(refreshEntry.Text)
+		if err := apply(settings); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		saveSettings(prefs, settings)
+	}, w)
+	form.Resize(fyne.NewSize(480, 0))
+	form.Show()
+}
+
+// newIntEntry returns an entry accepting positive integers
+func newIntEntry(value int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(value))
+	entry.Validator = func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return errors.New("a positive number is required")
+		}
+		return nil
+	}
+	return entry
+}
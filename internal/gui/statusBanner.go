@@ -0,0 +1,43 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package gui
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// statusBanner reports the log read errors above the graphs, without blocking the window
+type statusBanner struct {
+	*fyne.Container
+	message *widget.Label
+}
+
+// newStatusBanner returns a hidden banner, its Retry button calls retry
+func newStatusBanner(retry func()) *statusBanner {
+	message := widget.NewLabel("")
+	message.Wrapping = fyne.TextWrapWord
+
+	background := color.NRGBAModel.Convert(theme.Color(theme.ColorNameWarning)).(color.NRGBA)
+	background.A = 0x40
+
+	b := &statusBanner{message: message}
+	b.Container = container.NewStack(
+		canvas.NewRectangle(background),
+		container.NewBorder(nil, nil, widget.NewIcon(theme.WarningIcon()), widget.NewButton("Retry", retry), message),
+	)
+	b.Hide()
+	return b
+}
+
+// showMessage shows the banner with text
+func (b *statusBanner) showMessage(text string) {
+	b.message.SetText(text)
+	b.Show()
+}
@@ -0,0 +1,77 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package gui
+
+import (
+	"slices"
+	"time"
+)
+
+// TimeSelection is the cursor and the time range shared by the graphs of a window:
+// hovering one graph shows the cursor on all of them and zooming one zooms all of them
+type TimeSelection struct {
+	cursor    time.Time
+	from      time.Time
+	to        time.Time
+	listeners []listener
+	// lastID is the ID of the listener added last
+	lastID int
+}
+
+// listener is a function called after every change, identified to be removed
+type listener struct {
+	id int
+	fn func()
+}
+
+// NewTimeSelection returns a selection without cursor showing all the samples
+func NewTimeSelection() *TimeSelection {
+	return &TimeSelection{}
+}
+
+// AddListener registers fn to be called after every change, it returns the ID to remove it
+func (s *TimeSelection) AddListener(fn func()) int {
+	s.lastID++
+	s.listeners = append(s.listeners, listener{id: s.lastID, fn: fn})
+	return s.lastID
+}
+
+// RemoveListener unregisters the listener with the given ID
+func (s *TimeSelection) RemoveListener(id int) {
+	s.listeners = slices.DeleteFunc(s.listeners, func(l listener) bool { return l.id == id })
+}
+
+// Cursor returns the time under the mouse, zero if no graph is hovered
+func (s *TimeSelection) Cursor() time.Time {
+	return s.cursor
+}
+
+// SetCursor moves the cursor to t, zero hides it
+func (s *TimeSelection) SetCursor(t time.Time) {
+	if t.Equal(s.cursor) {
+		return
+	}
+	s.cursor = t
+	s.notify()
+}
+
+// Range returns the time range shown, zero times when all the samples are shown
+func (s *TimeSelection) Range() (time.Time, time.Time) {
+	return s.from, s.to
+}
+
+// SetRange shows the time range from to, zero times show all the samples
+func (s *TimeSelection) SetRange(from, to time.Time) {
+	if from.Equal(s.from) && to.Equal(s.to) {
+		return
+	}
+	s.from, s.to = from, to
+	s.notify()
+}
+
+func (s *TimeSelection) notify() {
+	for _, l := range s.listeners {
+		l.fn()
+	}
+}
@@ -0,0 +1,59 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// alignTolerance is how close samples must be to share a row when aligning series.
+// DCV writes stats once a minute, so half a period groups the lines of one stats dump
+// and the dumps of different connections in the same minute.
+const alignTolerance = 30 * time.Second
+
+// Align merge-joins several series on their timestamps. Every series must be sorted by
+// time, the value of aggregates[i] is taken from entries[i].
+// It returns the timestamp of each row and, for each series, one value per row with
+// NaN where the series has no sample. A row holds at most one sample of each series:
+// a second sample of a series within alignTolerance, e.g. when DCV logs stats more than
+// once a minute, starts a new row instead of replacing the first one.
+func Align(entries [][]LogEntry, aggregates []Aggregate) ([]time.Time, [][]float64) {
+	type sample struct {
+		timestamp time.Time
+		series    int
+		value     float64
+	}
+	var samples []sample
+	for i, seriesEntries := range entries {
+		for _, entry := range seriesEntries {
+			samples = append(samples, sample{entry.Timestamp, i, entry.Value(aggregates[i])})
+		}
+	}
+	slices.SortStableFunc(samples, func(a, b sample) int { return a.timestamp.Compare(b.timestamp) })
+
+	// Each row starts at the first sample not within alignTolerance of the previous row,
+	// or at the second sample of a series in the previous row
+	var rows []time.Time
+	values := make([][]float64, len(entries))
+	lastRow := make([]int, len(entries))
+	for i := range lastRow {
+		lastRow[i] = -1
+	}
+	for _, s := range samples {
+		row := len(rows) - 1
+		if row < 0 || s.timestamp.Sub(rows[row]) >= alignTolerance || lastRow[s.series] == row {
+			rows = append(rows, s.timestamp)
+			for i := range values {
+				values[i] = append(values[i], math.NaN())
+			}
+			row++
+		}
+		values[s.series][row] = s.value
+		lastRow[s.series] = row
+	}
+
+	return rows, values
+}
@@ -0,0 +1,84 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAlign(t *testing.T) {
+	start := time.Date(2025, 9, 26, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	entry := func(d time.Duration, last float64) LogEntry { return LogEntry{Timestamp: at(d), Last: last} }
+	nan := math.NaN()
+
+	tests := []struct {
+		name    string
+		entries [][]LogEntry
+		rows    []time.Time
+		values  [][]float64
+	}{
+		{
+			name:    "empty",
+			entries: [][]LogEntry{nil, nil},
+			values:  [][]float64{nil, nil},
+		},
+		{
+			name: "same minute",
+			entries: [][]LogEntry{
+				{entry(0, 1), entry(time.Minute, 2)},
+				{entry(time.Second, 10), entry(time.Minute+time.Second, 20)},
+			},
+			rows:   []time.Time{at(0), at(time.Minute)},
+			values: [][]float64{{1, 2}, {10, 20}},
+		},
+		{
+			name: "gaps",
+			entries: [][]LogEntry{
+				{entry(0, 1), entry(2*time.Minute, 3)},
+				{entry(time.Minute, 20), entry(2*time.Minute+time.Second, 30)},
+			},
+			rows:   []time.Time{at(0), at(time.Minute), at(2 * time.Minute)},
+			values: [][]float64{{1, nan, 3}, {nan, 20, 30}},
+		},
+		{
+			name: "series without samples",
+			entries: [][]LogEntry{
+				{entry(0, 1), entry(time.Minute, 2)},
+				nil,
+			},
+			rows:   []time.Time{at(0), at(time.Minute)},
+			values: [][]float64{{1, 2}, {nan, nan}},
+		},
+		{
+			name: "two samples of a series within the tolerance",
+			entries: [][]LogEntry{
+				{entry(0, 1), entry(10*time.Second, 2), entry(time.Minute, 3)},
+				{entry(5*time.Second, 10), entry(time.Minute, 30)},
+			},
+			rows:   []time.Time{at(0), at(10 * time.Second), at(time.Minute)},
+			values: [][]float64{{1, 2, 3}, {10, nan, 30}},
+		},
+	}
+	for _, test := range tests {
+		aggregates := make([]Aggregate, len(test.entries))
+		rows, values := Align(test.entries, aggregates)
+		if !slices.EqualFunc(rows, test.rows, time.Time.Equal) {
+			t.Errorf("%s: rows = %v, want %v", test.name, rows, test.rows)
+		}
+		if !slices.EqualFunc(values, test.values, equalValues) {
+			t.Errorf("%s: values = %v, want %v", test.name, values, test.values)
+		}
+	}
+}
+
+// equalValues compares series values, NaN being equal to NaN
+func equalValues(a, b []float64) bool {
+	return slices.EqualFunc(a, b, func(x, y float64) bool {
+		return x == y || math.IsNaN(x) && math.IsNaN(y)
+	})
+}
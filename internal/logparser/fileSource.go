@@ -0,0 +1,221 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dcvix/dcvix-stats/internal/logger"
+)
+
+// FileSource tails a log file: each Read returns the lines appended since the previous one.
+// When the file is rotated the end of the rotated file is read before the new file,
+// when it is truncated, e.g. by logrotate copytruncate, it is read again from the start.
+type FileSource struct {
+	path    string
+	history bool
+
+	// tail state: the file read so far and the offset of the first unread line
+	fileInfo os.FileInfo
+	offset   int64
+	// tail holds the last bytes read, a file truncated and written again up to offset
+	// doesn't have them before offset anymore
+	tail []byte
+
+	// archives are the rotated files not yet read by ReadOlder, newest first
+	archives       []string
+	archivesListed bool
+}
+
+// NewFileSource returns a source tailing path, with history ReadOlder reads its rotated
+// files: path.1, path.2.gz, ...
+func NewFileSource(path string, history bool) *FileSource {
+	return &FileSource{path: path, history: history}
+}
+
+func (s *FileSource) Read(line func(string)) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case s.fileInfo == nil:
+		logger.LogVerbosef("Reading log file %s\n", s.path)
+	case !os.SameFile(s.fileInfo, info):
+		logger.LogVerbosef("Log file %s was rotated\n", s.path)
+		s.readRotatedTail(line)
+		s.offset = 0
+		s.tail = nil
+	case info.Size() < s.offset || s.tailChanged(file):
+		logger.LogVerbosef("Log file %s was truncated\n", s.path)
+		s.offset = 0
+	}
+	s.fileInfo = info
+
+	if info.Size() == s.offset {
+		return nil
+	}
+	if _, err := file.Seek(s.offset, io.SeekStart); err != nil {
+		return err
+	}
+	// An incomplete last line is left for the next read, once DCV finishes writing it
+	n, err := scanLines(file, false, line)
+	s.offset += n
+	s.readTail(file)
+	return err
+}
+
+// tailSize is the number of bytes before the offset compared to detect a truncation
+const tailSize = 256
+
+// readTail records the bytes before the offset
+func (s *FileSource) readTail(file *os.File) {
+	s.tail = make([]byte, min(s.offset, tailSize))
+	if _, err := file.ReadAt(s.tail, s.offset-int64(len(s.tail))); err != nil {
+		s.tail = nil
+	}
+}
+
+// tailChanged reports whether the bytes before the offset are not the ones read last
+func (s *FileSource) tailChanged(file *os.File) bool {
+	if len(s.tail) == 0 {
+		return false
+	}
+	buf := make([]byte, len(s.tail))
+	if _, err := file.ReadAt(buf, s.offset-int64(len(buf))); err != nil {
+		return true
+	}
+	return !bytes.Equal(buf, s.tail)
+}
+
+// ReadOlder reads the next rotated file, if history is enabled
+func (s *FileSource) ReadOlder(line func(string)) (bool, error) {
+	if !s.history {
+		return false, nil
+	}
+	if !s.archivesListed {
+		s.archives = s.rotatedFiles()
+		s.archivesListed = true
+	}
+	if len(s.archives) == 0 {
+		return false, nil
+	}
+
+	path := s.archives[0]
+	s.archives = s.archives[1:]
+	logger.LogVerbosef("Reading rotated log %s\n", path)
+	return true, readArchive(path, line)
+}
+
+func (s *FileSource) Name() string {
+	return s.path
+}
+
+func (s *FileSource) Close() error {
+	return nil
+}
+
+func (s *FileSource) WatchPaths() []string {
+	return []string{s.path}
+}
+
+func (s *FileSource) Matches(path string) bool {
+	return filepath.Clean(path) == filepath.Clean(s.path)
+}
+
+// rotatedFiles returns the rotated siblings of the log file, newest first:
+// server.log.1, server.log.2.gz, ...
+func (s *FileSource) rotatedFiles() []string {
+	dir, base := filepath.Split(s.path)
+	dirEntries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil
+	}
+
+	type rotatedFile struct {
+		path  string
+		index int
+	}
+	var rotated []rotatedFile
+	for _, dirEntry := range dirEntries {
+		suffix, found := strings.CutPrefix(dirEntry.Name(), base+".")
+		if !found || dirEntry.IsDir() {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimSuffix(suffix, ".gz"))
+		if err != nil {
+			continue
+		}
+		rotated = append(rotated, rotatedFile{path: filepath.Join(dir, dirEntry.Name()), index: index})
+	}
+	sort.Slice(rotated, func(i, j int) bool { return rotated[i].index < rotated[j].index })
+
+	paths := make([]string, len(rotated))
+	for i, r := range rotated {
+		paths[i] = r.path
+	}
+	return paths
+}
+
+// readRotatedTail reads the lines written to the log file after the previous read and
+// before it was rotated, if the rotated file can still be found uncompressed
+func (s *FileSource) readRotatedTail(line func(string)) {
+	for _, path := range s.rotatedFiles() {
+		info, err := os.Stat(path)
+		if err != nil || !os.SameFile(s.fileInfo, info) {
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			logger.LogVerbosef("Error opening rotated log %s: %v\n", path, err)
+			return
+		}
+		defer file.Close()
+
+		if _, err := file.Seek(s.offset, io.SeekStart); err != nil {
+			return
+		}
+		if _, err := scanLines(file, true, line); err != nil {
+			logger.LogVerbosef("Error reading rotated log %s: %v\n", path, err)
+		}
+		return
+	}
+}
+
+// readArchive reads a whole rotated log file, gzip compressed if its name ends in .gz
+func readArchive(path string, line func(string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	_, err = scanLines(r, true, line)
+	return err
+}
@@ -0,0 +1,130 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// readLines returns the lines of a Read of source
+func readLines(t *testing.T, source Source) []string {
+	t.Helper()
+	var lines []string
+	if err := source.Read(func(line string) { lines = append(lines, line) }); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+func writeFile(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendFile(t *testing.T, path string, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkLines(t *testing.T, step string, got []string, want ...string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("%s: read %q, want %q", step, got, want)
+	}
+}
+
+func TestFileSourceTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	writeFile(t, path, "line 1\nline 2\n")
+	source := NewFileSource(path, false)
+
+	checkLines(t, "first read", readLines(t, source), "line 1", "line 2")
+	checkLines(t, "unchanged", readLines(t, source))
+
+	appendFile(t, path, "line 3\nline ")
+	checkLines(t, "partial line", readLines(t, source), "line 3")
+	appendFile(t, path, "4\n")
+	checkLines(t, "completed line", readLines(t, source), "line 4")
+}
+
+func TestFileSourceRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	writeFile(t, path, "line 1\n")
+	source := NewFileSource(path, false)
+	checkLines(t, "first read", readLines(t, source), "line 1")
+
+	// Lines written before the rotation are read from the rotated file
+	appendFile(t, path, "line 2\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "line 3\n")
+	checkLines(t, "rotated", readLines(t, source), "line 2", "line 3")
+}
+
+func TestFileSourceTruncation(t *testing.T) {
+	tests := []struct {
+		name   string
+		refill string
+		want   []string
+	}{
+		{"shorter", "new 1\n", []string{"new 1"}},
+		{"same size", "new line 1\n", []string{"new line 1"}},
+		{"longer", "new line 1\nnew line 2\n", []string{"new line 1", "new line 2"}},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "server.log")
+		writeFile(t, path, "old line 1\n")
+		source := NewFileSource(path, false)
+		checkLines(t, test.name+" first read", readLines(t, source), "old line 1")
+
+		// copytruncate empties the file, DCV writes again from the start
+		writeFile(t, path, test.refill)
+		checkLines(t, test.name+" truncated", readLines(t, source), test.want...)
+	}
+}
+
+func TestFileSourceHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	writeFile(t, path, "current\n")
+	writeFile(t, path+".1", "rotated 1\n")
+
+	file, err := os.Create(path + ".2.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte("rotated 2\n"))
+	gz.Close()
+	file.Close()
+
+	source := NewFileSource(path, true)
+	checkLines(t, "current", readLines(t, source), "current")
+	for _, want := range []string{"rotated 1", "rotated 2"} {
+		var lines []string
+		more, err := source.ReadOlder(func(line string) { lines = append(lines, line) })
+		if err != nil || !more {
+			t.Fatalf("ReadOlder = %v, %v, want more history", more, err)
+		}
+		checkLines(t, "history", lines, want)
+	}
+	if more, _ := source.ReadOlder(func(string) {}); more {
+		t.Error("ReadOlder returned more history than the rotated files")
+	}
+}
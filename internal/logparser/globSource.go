@@ -0,0 +1,68 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// GlobSource tails all the files matching a glob pattern, files created later are
+// picked up by the next Read
+type GlobSource struct {
+	pattern string
+	files   map[string]*FileSource
+}
+
+// NewGlobSource returns a source reading the files matching pattern (see filepath.Match)
+func NewGlobSource(pattern string) *GlobSource {
+	return &GlobSource{pattern: pattern, files: make(map[string]*FileSource)}
+}
+
+func (s *GlobSource) Read(line func(string)) error {
+	paths, err := filepath.Glob(s.pattern)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("%s: %w", s.pattern, fs.ErrNotExist)
+	}
+
+	var errs []error
+	for _, path := range paths {
+		file, found := s.files[path]
+		if !found {
+			file = NewFileSource(path, false)
+			s.files[path] = file
+		}
+		if err := file.Read(line); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *GlobSource) Name() string {
+	return s.pattern
+}
+
+func (s *GlobSource) Close() error {
+	return nil
+}
+
+// WatchPaths returns the files matching the pattern, or its directory if there are none yet
+func (s *GlobSource) WatchPaths() []string {
+	paths, _ := filepath.Glob(s.pattern)
+	if len(paths) == 0 {
+		return []string{filepath.Dir(s.pattern)}
+	}
+	return paths
+}
+
+func (s *GlobSource) Matches(path string) bool {
+	matched, _ := filepath.Match(s.pattern, path)
+	return matched
+}
@@ -4,162 +4,500 @@
 package logparser
 
 import (
-	"bufio"
-	"os"
+	"fmt"
+	"math"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dcvix/dcvix-stats/internal/globals"
 	"github.com/dcvix/dcvix-stats/internal/logger"
 )
 
+// AllConnections selects the samples of every DCV connection at once.
+const AllConnections = -1
+
+// Aggregate selects one of the values DCV logs for every metric
+type Aggregate int
+
+const (
+	Last Aggregate = iota
+	Sum
+	Max
+	Avg
+)
+
+// aggregateSuffixes maps series name suffixes to the aggregate they select,
+// e.g. "quic_rtt_nanos_avg" is the avg of quic_rtt_nanos
+var aggregateSuffixes = map[string]Aggregate{
+	"_last": Last,
+	"_sum":  Sum,
+	"_max":  Max,
+	"_avg":  Avg,
+}
+
 type LogEntry struct {
-	Timestamp string
-	Metric    string
-	LastValue float64
+	Timestamp time.Time
+	// Connection is the DCV connection ID, 0 if the line doesn't carry one
+	Connection int
+	Metric     string
+	Sum        float64
+	Last       float64
+	Max        float64
+	Avg        float64
+}
+
+// Value returns the requested aggregate of the entry
+func (e LogEntry) Value(agg Aggregate) float64 {
+	switch agg {
+	case Sum:
+		return e.Sum
+	case Max:
+		return e.Max
+	case Avg:
+		return e.Avg
+	default:
+		return e.Last
+	}
+}
+
+// Series is a window of values of one metric aggregate, ready to be charted
+type Series struct {
+	Label     string
+	Aggregate Aggregate
+	Values    []float64
+}
+
+// SplitSeriesName splits a series name like "quic_rtt_nanos_avg" into the logged metric
+// and the aggregate to show, names without a known suffix select the last value
+func SplitSeriesName(name string) (string, Aggregate) {
+	for suffix, agg := range aggregateSuffixes {
+		if metric, found := strings.CutSuffix(name, suffix); found {
+			return metric, agg
+		}
+	}
+	return name, Last
+}
+
+// SeriesKey identifies the samples of one metric on one DCV connection.
+type SeriesKey struct {
+	Connection int
+	Metric     string
 }
 
 type LogParser struct {
-	filename string
-	metrics  []string
-	entries  []LogEntry
-	regex    *regexp.Regexp
+	source  Source
+	metrics map[string]bool
+	// discover records metrics not in metrics too, their names are listed in discovered
+	discover    bool
+	discovered  []string
+	series      map[SeriesKey][]LogEntry
+	connections []int
+	regex       *regexp.Regexp
+	// versionRegex matches the DCV server version logged at startup
+	versionRegex  *regexp.Regexp
+	serverVersion string
+	// entriesQty is the number of samples of the window returned by GetEntriesByMetricList
+	entriesQty int
+	// historyLoaded is set once the history of the source was read, after the first read
+	historyLoaded bool
+	// retention, when not 0, is the number of latest entries kept in every series
+	retention int
+
+	health Health
+}
+
+// Health counts the lines processed by the parser
+type Health struct {
+	// LinesRead counts all the log lines read
+	LinesRead uint64
+	// LinesRejected counts the stats lines that could not be parsed
+	LinesRejected uint64
+	// LastRead is the time of the last successful ReadLogFile
+	LastRead time.Time
 }
 
-func NewLogParser(filename string) *LogParser {
+// NewLogParser returns a parser of the lines of source keeping only the given metrics
+func NewLogParser(source Source, metrics []string) *LogParser {
 
-	// Regex to match the log line and extract timestamp, metric, and last value
+	// Regex to match the log line and extract timestamp, connection, metric and the sum, last, max, avg values
 	// 2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 3 - Stats (1): quic_lost_packets: [sum: 221, last: 221, max: 221, avg: 221.00]
-	// regex := regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}),\d+ .* (quic_\w+|intermediates_rtt_nanos): \[.*last: ([0-9.]+),.*\]`)
-	regex := regexp.MustCompile(`^(\S+\s+\S+),.*Stats \(\d+\): (\S+):.*last: ([0-9]+).*avg: ([0-9]+)`)
+	// values are validated by strconv.ParseFloat so decimals, negative and scientific notations are accepted
+	// the timestamp is searched anywhere in the line to accept the prefixes added by syslog or journalctl
+	regex := regexp.MustCompile(`(\d{4}-\d{2}-\d{2}\s+\d{2}:\d{2}:\d{2},\d+).*?(?:Connection (\d+) - )?Stats \(\d+\): (\S+): \[sum: ([^,\]]+), last: ([^,\]]+), max: ([^,\]]+), avg: ([^,\]]+)\]`)
+
+	metricSet := make(map[string]bool, len(metrics))
+	for _, m := range metrics {
+		metricSet[m] = true
+	}
+
+	// DCV versions are year based, e.g. "Server version: 2024.0.17979"
+	versionRegex := regexp.MustCompile(`(?i)version:?\s+(20\d{2}\.\d+(?:\.\d+)*)`)
 
 	return &LogParser{
-		filename: filename,
-		metrics:  globals.Metrics,
-		entries:  make([]LogEntry, 0),
-		regex:    regex,
+		source:       source,
+		entriesQty:   globals.LogEntriesQty,
+		metrics:      metricSet,
+		versionRegex: versionRegex,
+		series:       make(map[SeriesKey][]LogEntry),
+		regex:        regex,
 	}
 }
 
+// ReadLogFile parses the lines received from the source since the previous call.
+// After the first read the history of the source, if any, is parsed until the
+// entries window is filled (see HistorySource).
 func (lp *LogParser) ReadLogFile() error {
-	file, err := os.Open(lp.filename)
+	err := lp.source.Read(lp.readLine)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	var newEntries []LogEntry
-	scanner := bufio.NewScanner(file)
+	if !lp.historyLoaded {
+		lp.historyLoaded = true
+		if history, ok := lp.source.(HistorySource); ok {
+			lp.loadHistory(history)
+		}
+	}
+	lp.trim()
+	lp.health.LastRead = time.Now()
+	return nil
+}
+
+// SetRetention keeps only the latest n entries of every series, so that a parser reading
+// a log for days doesn't grow without bounds. 0, the default, keeps all the entries.
+func (lp *LogParser) SetRetention(n int) {
+	lp.retention = n
+	lp.trim()
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		entry := lp.parseLine(line)
-		if entry != nil {
-			newEntries = append(newEntries, entry...)
+// trim drops the entries older than the retention
+func (lp *LogParser) trim() {
+	if lp.retention <= 0 {
+		return
+	}
+	for key, entries := range lp.series {
+		if len(entries) > lp.retention {
+			// Cloned so that the dropped entries are released
+			lp.series[key] = slices.Clone(entries[len(entries)-lp.retention:])
 		}
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return err
+// Source returns the source of the log lines
+func (lp *LogParser) Source() Source {
+	return lp.source
+}
+
+// Close releases the source of the log lines
+func (lp *LogParser) Close() error {
+	return lp.source.Close()
+}
+
+// ServerVersion returns the DCV server version found in the log, empty if not found
+func (lp *LogParser) ServerVersion() string {
+	return lp.serverVersion
+}
+
+// EntriesQty returns the number of samples of the window, globals.LogEntriesQty by default
+func (lp *LogParser) EntriesQty() int {
+	return lp.entriesQty
+}
+
+// SetEntriesQty changes the number of samples of the window
+func (lp *LogParser) SetEntriesQty(n int) {
+	lp.entriesQty = n
+}
+
+// Health returns the parser line counters
+func (lp *LogParser) Health() Health {
+	return lp.health
+}
+
+// readLine parses a log line, adding the entry found to its series
+func (lp *LogParser) readLine(line string) {
+	if entry := lp.parseLine(line); entry != nil {
+		lp.addEntry(*entry)
 	}
+}
 
-	lp.entries = newEntries
-	return nil
+// addEntry adds entry to its series, lines from several files or streams can come out
+// of order so the series is kept sorted by timestamp
+func (lp *LogParser) addEntry(entry LogEntry) {
+	key := SeriesKey{Connection: entry.Connection, Metric: entry.Metric}
+	lp.addConnection(entry.Connection)
+
+	entries := lp.series[key]
+	if n := len(entries); n > 0 && entry.Timestamp.Before(entries[n-1].Timestamp) {
+		i, _ := slices.BinarySearchFunc(entries, entry.Timestamp, func(e LogEntry, t time.Time) int {
+			if e.Timestamp.After(t) {
+				return 1
+			}
+			return -1
+		})
+		lp.series[key] = slices.Insert(entries, i, entry)
+		return
+	}
+	lp.series[key] = append(entries, entry)
+}
+
+// loadHistory prepends the entries of the history of the source, newest chunk first,
+// until the longest series fills the entries window
+func (lp *LogParser) loadHistory(history HistorySource) {
+	for lp.longestSeries() < lp.entriesQty {
+		var older []LogEntry
+		more, err := history.ReadOlder(func(line string) {
+			if entry := lp.parseLine(line); entry != nil {
+				older = append(older, *entry)
+			}
+		})
+		if err != nil {
+			logger.LogVerbosef("Error reading log history of %s: %v\n", lp.source.Name(), err)
+			return
+		}
+		if !more {
+			return
+		}
+		lp.prependEntries(older)
+	}
+}
+
+// prependEntries inserts entries, older than every entry already parsed, at the start of their series
+func (lp *LogParser) prependEntries(entries []LogEntry) {
+	older := make(map[SeriesKey][]LogEntry)
+	for _, entry := range entries {
+		key := SeriesKey{Connection: entry.Connection, Metric: entry.Metric}
+		older[key] = append(older[key], entry)
+		lp.addConnection(entry.Connection)
+	}
+	for key, olderEntries := range older {
+		lp.series[key] = append(olderEntries, lp.series[key]...)
+	}
+}
+
+// longestSeries returns the number of entries of the longest series
+func (lp *LogParser) longestSeries() int {
+	longest := 0
+	for _, entries := range lp.series {
+		longest = max(longest, len(entries))
+	}
+	return longest
+}
+
+// addConnection records a connection ID, keeping the list sorted
+func (lp *LogParser) addConnection(connection int) {
+	if !slices.Contains(lp.connections, connection) {
+		lp.connections = append(lp.connections, connection)
+		slices.Sort(lp.connections)
+	}
 }
 
-func (lp *LogParser) parseLine(line string) []LogEntry {
+func (lp *LogParser) parseLine(line string) *LogEntry {
+	lp.health.LinesRead++
 	matches := lp.regex.FindStringSubmatch(line)
-	if len(matches) != 5 {
+	if len(matches) != 8 {
+		if strings.Contains(line, "Stats (") {
+			lp.health.LinesRejected++
+		} else if version := lp.versionRegex.FindStringSubmatch(line); version != nil {
+			lp.serverVersion = version[1]
+		}
 		return nil
 	}
 
 	timestampUTC := matches[1]
-	metric := matches[2]
-	lastValueStr := matches[3]
-	avgValueStr := matches[4]
+	connectionStr := matches[2]
+	metric := matches[3]
 
 	// Check if this metric is one we're interested in
-	found := false
-	for _, m := range lp.metrics {
-		if m == metric {
-			found = true
-			break
+	if !lp.metrics[metric] {
+		if !lp.discover {
+			return nil
+		}
+		if !slices.Contains(lp.discovered, metric) {
+			logger.LogVerbosef("Discovered metric %s\n", metric)
+			lp.discovered = append(lp.discovered, metric)
+			slices.Sort(lp.discovered)
 		}
-	}
-	if !found {
-		return nil
 	}
 
-	// convert UTC timestamp to local time
-	utcTime, err := time.ParseInLocation("2006-01-02 15:04:05", timestampUTC, time.UTC)
+	// DCV logs UTC timestamps with microseconds after the comma, parsed as fractional seconds
+	timestamp, err := time.ParseInLocation("2006-01-02 15:04:05", timestampUTC, time.UTC)
 	if err != nil {
 		logger.LogVerbosef("Error parsing timestamp: %v", err)
+		lp.health.LinesRejected++
 		return nil
 	}
-	localTime := utcTime.Local()
-	timestampLocalTime := localTime.Format("15:04:05")
 
-	lastValue, err := strconv.ParseFloat(lastValueStr, 64)
-	if err != nil {
-		return nil
+	connection := 0
+	if connectionStr != "" {
+		connection, err = strconv.Atoi(connectionStr)
+		if err != nil {
+			lp.health.LinesRejected++
+			return nil
+		}
 	}
 
-	avgValue, err := strconv.ParseFloat(avgValueStr, 64)
-	if err != nil {
-		return nil
+	var values [4]float64
+	for i, valueStr := range matches[4:8] {
+		values[i], err = strconv.ParseFloat(strings.TrimSpace(valueStr), 64)
+		if err != nil {
+			logger.LogVerbosef("Error parsing value %q of %s: %v", valueStr, metric, err)
+			lp.health.LinesRejected++
+			return nil
+		}
+		// ParseFloat accepts inf and nan, they can't be charted nor exported
+		if math.IsNaN(values[i]) || math.IsInf(values[i], 0) {
+			logger.LogVerbosef("Invalid value %q of %s", valueStr, metric)
+			lp.health.LinesRejected++
+			return nil
+		}
 	}
 
-	valEntry := LogEntry{
-		Timestamp: timestampLocalTime,
-		Metric:    metric,
-		LastValue: lastValue,
+	return &LogEntry{
+		Timestamp:  timestamp,
+		Connection: connection,
+		Metric:     metric,
+		Sum:        values[0],
+		Last:       values[1],
+		Max:        values[2],
+		Avg:        values[3],
 	}
+}
 
-	avgEntry := LogEntry{
-		Timestamp: timestampLocalTime,
-		Metric:    metric + "_avg",
-		LastValue: avgValue,
+// GetEntriesInRange returns the samples of a series with a timestamp between from and to
+// included, a zero from or to means no bound
+func (lp *LogParser) GetEntriesInRange(key SeriesKey, from, to time.Time) []LogEntry {
+	entries := lp.series[key]
+	start, end := 0, len(entries)
+	if !from.IsZero() {
+		start, _ = slices.BinarySearchFunc(entries, from, func(e LogEntry, t time.Time) int {
+			return e.Timestamp.Compare(t)
+		})
 	}
-
-	res := []LogEntry{valEntry, avgEntry}
-	return res
+	if !to.IsZero() {
+		end, _ = slices.BinarySearchFunc(entries, to, func(e LogEntry, t time.Time) int {
+			if e.Timestamp.After(t) {
+				return 1
+			}
+			return -1
+		})
+	}
+	if start >= end {
+		return nil
+	}
+	return entries[start:end]
 }
 
-func (lp *LogParser) GetEntriesByMetric(metric string) []LogEntry {
-	var entries []LogEntry
-	for _, entry := range lp.entries {
-		if entry.Metric == metric {
-			entries = append(entries, entry)
+// LastTimestamp returns the most recent timestamp parsed, zero if no entries were found
+func (lp *LogParser) LastTimestamp() time.Time {
+	var last time.Time
+	for _, entries := range lp.series {
+		if len(entries) > 0 && entries[len(entries)-1].Timestamp.After(last) {
+			last = entries[len(entries)-1].Timestamp
 		}
 	}
-	return entries
+	return last
 }
 
-func (lp *LogParser) GetEntriesByMetricList(metrics []string) ([][]float64, []string) {
-	var values [][]float64
-	var timeStamps []string
+// SetDiscovery enables parsing the metrics not in the list given to NewLogParser,
+// it applies to the lines read from now on
+func (lp *LogParser) SetDiscovery(enabled bool) {
+	lp.discover = enabled
+}
 
-	for i, metric := range metrics {
-		entries := lp.GetEntriesByMetric(metric)
+// DiscoveredMetrics returns the sorted names of the metrics found in discovery mode
+// that are not in the list given to NewLogParser
+func (lp *LogParser) DiscoveredMetrics() []string {
+	return lp.discovered
+}
 
-		start := 0
-		if len(entries) > globals.LogEntriesQty {
-			start = len(entries) - globals.LogEntriesQty
+// Connections returns the IDs of the DCV connections seen in the log, in ascending order
+func (lp *LogParser) Connections() []int {
+	return lp.connections
+}
+
+// SeriesKeys returns the keys of all the parsed series, sorted by connection and metric
+func (lp *LogParser) SeriesKeys() []SeriesKey {
+	keys := make([]SeriesKey, 0, len(lp.series))
+	for key := range lp.series {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b SeriesKey) int {
+		if a.Connection != b.Connection {
+			return a.Connection - b.Connection
 		}
+		return strings.Compare(a.Metric, b.Metric)
+	})
+	return keys
+}
+
+// GetEntriesBySeries returns all the samples of one metric on one connection
+func (lp *LogParser) GetEntriesBySeries(key SeriesKey) []LogEntry {
+	return lp.series[key]
+}
+
+// GetEntriesByMetricList returns the last EntriesQty samples of each metric on
+// the given connection aligned on their timestamps (see Align), metric names can carry an
+// aggregate suffix (see SplitSeriesName).
+// With AllConnections the series of every connection are returned side by side.
+func (lp *LogParser) GetEntriesByMetricList(metrics []string, connection int) ([]Series, []time.Time) {
+	var candidates []Series
+	var entriesList [][]LogEntry
+	var aggregates []Aggregate
 
-		var metricValues []float64
-		for j := start; j < len(entries); j++ {
-			metricValues = append(metricValues, entries[j].LastValue)
-			// Only add timestamps for the first metric to avoid duplicates
-			if i == 0 {
-				timeStamps = append(timeStamps, entries[j].Timestamp)
+	connections := []int{connection}
+	if connection == AllConnections {
+		connections = lp.connections
+	}
+
+	for _, conn := range connections {
+		for _, name := range metrics {
+			metric, agg := SplitSeriesName(name)
+			entries := lp.GetEntriesBySeries(SeriesKey{Connection: conn, Metric: metric})
+			if len(entries) == 0 {
+				continue
+			}
+
+			// A series has at most one sample per row, older samples can't be in the window
+			if len(entries) > lp.entriesQty {
+				entries = entries[len(entries)-lp.entriesQty:]
 			}
+
+			candidates = append(candidates, Series{
+				Label:     seriesLabel(name, conn, len(connections) > 1),
+				Aggregate: agg,
+			})
+			entriesList = append(entriesList, entries)
+			aggregates = append(aggregates, agg)
 		}
-		if len(metricValues) > 0 {
-			values = append(values, metricValues)
+	}
+
+	timeStamps, values := Align(entriesList, aggregates)
+
+	start := 0
+	if len(timeStamps) > lp.entriesQty {
+		start = len(timeStamps) - lp.entriesQty
+	}
+
+	var series []Series
+	for i, s := range candidates {
+		s.Values = values[i][start:]
+		if !slices.ContainsFunc(s.Values, func(v float64) bool { return !math.IsNaN(v) }) {
+			continue
 		}
+		series = append(series, s)
 	}
 
-	return values, timeStamps
+	return series, timeStamps[start:]
+}
+
+// seriesLabel returns the legend label of a series, naming the connection only when
+// several of them are shown together
+func seriesLabel(metric string, connection int, withConnection bool) string {
+	if !withConnection || connection == 0 {
+		return metric
+	}
+	return fmt.Sprintf("%s #%d", metric, connection)
 }
@@ -0,0 +1,119 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	timestamp := time.Date(2025, 9, 26, 10, 39, 33, 895159000, time.UTC)
+	tests := []struct {
+		name string
+		line string
+		want *LogEntry
+	}{
+		{
+			name: "connection",
+			line: "2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 3 - Stats (1): quic_lost_packets: [sum: 221, last: 221, max: 221, avg: 221.00]",
+			want: &LogEntry{Timestamp: timestamp, Connection: 3, Metric: "quic_lost_packets", Sum: 221, Last: 221, Max: 221, Avg: 221},
+		},
+		{
+			name: "no connection",
+			line: "2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Stats (1): quic_rtt_nanos: [sum: 100, last: 10, max: 20, avg: 15.50]",
+			want: &LogEntry{Timestamp: timestamp, Connection: 0, Metric: "quic_rtt_nanos", Sum: 100, Last: 10, Max: 20, Avg: 15.5},
+		},
+		{
+			name: "decimal, negative and scientific values",
+			line: "2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: 1.5e3, last: -2, max: 0.25, avg: 1E-2]",
+			want: &LogEntry{Timestamp: timestamp, Connection: 1, Metric: "quic_rtt_nanos", Sum: 1500, Last: -2, Max: 0.25, Avg: 0.01},
+		},
+		{
+			name: "syslog prefix",
+			line: "<30>Sep 26 10:39:33 dcv-host dcvserver[1139]: 2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 2 - Stats (1): quic_rtt_nanos: [sum: 4, last: 3, max: 2, avg: 1.00]",
+			want: &LogEntry{Timestamp: timestamp, Connection: 2, Metric: "quic_rtt_nanos", Sum: 4, Last: 3, Max: 2, Avg: 1},
+		},
+		{
+			name: "journalctl prefix",
+			line: "Sep 26 10:39:33 dcv-host dcvserver[1139]: 2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Stats (1): quic_rtt_nanos: [sum: 4, last: 3, max: 2, avg: 1.00]",
+			want: &LogEntry{Timestamp: timestamp, Metric: "quic_rtt_nanos", Sum: 4, Last: 3, Max: 2, Avg: 1},
+		},
+		{
+			name: "infinite value",
+			line: "2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: inf, last: 1, max: 1, avg: 1.00]",
+		},
+		{
+			name: "NaN value",
+			line: "2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: 1, last: NaN, max: 1, avg: 1.00]",
+		},
+		{
+			name: "not a number",
+			line: "2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: 1, last: n/a, max: 1, avg: 1.00]",
+		},
+		{
+			name: "unknown metric",
+			line: "2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): other_metric: [sum: 1, last: 1, max: 1, avg: 1.00]",
+		},
+		{
+			name: "not a stats line",
+			line: "2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  server - Client connected",
+		},
+	}
+
+	for _, test := range tests {
+		parser := NewLogParser(nil, []string{"quic_lost_packets", "quic_rtt_nanos"})
+		got := parser.parseLine(test.line)
+		switch {
+		case test.want == nil && got != nil:
+			t.Errorf("%s: got %+v, want no entry", test.name, *got)
+		case test.want != nil && got == nil:
+			t.Errorf("%s: no entry, want %+v", test.name, *test.want)
+		case test.want != nil && (!got.Timestamp.Equal(test.want.Timestamp) || got.Connection != test.want.Connection ||
+			got.Metric != test.want.Metric || got.Sum != test.want.Sum || got.Last != test.want.Last ||
+			got.Max != test.want.Max || got.Avg != test.want.Avg):
+			t.Errorf("%s: got %+v, want %+v", test.name, *got, *test.want)
+		}
+	}
+}
+
+func TestParseLineHealth(t *testing.T) {
+	parser := NewLogParser(nil, []string{"quic_rtt_nanos"})
+	for _, line := range []string{
+		"2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: 1, last: 1, max: 1, avg: 1.00]",
+		"2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: inf, last: 1, max: 1, avg: 1.00]",
+		"2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  quictransport - Connection 1 - Stats (1): quic_rtt_nanos: [sum: 1, last: 1]",
+		"2025-09-26 10:39:33,895159 [  1139:1139  ] INFO  server - Server version: 2024.0.17979",
+	} {
+		parser.parseLine(line)
+	}
+
+	health := parser.Health()
+	if health.LinesRead != 4 || health.LinesRejected != 2 {
+		t.Errorf("read %d lines, rejected %d, want 4 and 2", health.LinesRead, health.LinesRejected)
+	}
+	if version := parser.ServerVersion(); version != "2024.0.17979" {
+		t.Errorf("server version %q, want 2024.0.17979", version)
+	}
+}
+
+func TestSplitSeriesName(t *testing.T) {
+	tests := []struct {
+		name      string
+		metric    string
+		aggregate Aggregate
+	}{
+		{"quic_rtt_nanos", "quic_rtt_nanos", Last},
+		{"quic_rtt_nanos_avg", "quic_rtt_nanos", Avg},
+		{"quic_rtt_nanos_max", "quic_rtt_nanos", Max},
+		{"quic_rtt_nanos_sum", "quic_rtt_nanos", Sum},
+		{"quic_rtt_nanos_last", "quic_rtt_nanos", Last},
+	}
+	for _, test := range tests {
+		metric, aggregate := SplitSeriesName(test.name)
+		if metric != test.metric || aggregate != test.aggregate {
+			t.Errorf("SplitSeriesName(%q) = %q, %v, want %q, %v", test.name, metric, aggregate, test.metric, test.aggregate)
+		}
+	}
+}
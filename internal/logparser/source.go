@@ -0,0 +1,164 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxBufferedLines bounds the lines a stream source keeps between two reads,
+// the oldest ones are dropped
+const maxBufferedLines = 100000
+
+// Source provides DCV log lines to a LogParser
+type Source interface {
+	// Read calls line for every log line received since the previous call
+	Read(line func(string)) error
+	// Name describes the source, e.g. the file path
+	Name() string
+	Close() error
+}
+
+// HistorySource is a Source that can also provide lines older than the ones read so far,
+// e.g. from rotated log files
+type HistorySource interface {
+	Source
+	// ReadOlder calls line for every line of the next older chunk of history, newest chunk
+	// first. It returns false when there is no more history.
+	ReadOlder(line func(string)) (bool, error)
+}
+
+// OpenSource returns the source described by spec:
+//
+//	/var/log/dcv/server.log  a single file, with history from its rotated files if history is true
+//	/var/log/dcv/*.log       files matching a glob pattern
+//	/var/log/dcv             the *.log files of a directory
+//	syslog+udp://:5514       syslog messages received over UDP
+//	syslog+tcp://:5514       syslog messages received over TCP
+//	-                        standard input, e.g. journalctl -f | dcvix-stats --logfile -
+func OpenSource(spec string, history bool) (Source, error) {
+	if spec == "-" {
+		return NewReaderSource("stdin", os.Stdin), nil
+	}
+	if network, address, found := strings.Cut(spec, "://"); found && strings.HasPrefix(network, "syslog+") {
+		return NewSyslogSource(strings.TrimPrefix(network, "syslog+"), address)
+	}
+	if strings.ContainsAny(spec, "*?[") {
+		return NewGlobSource(spec), nil
+	}
+	if info, err := os.Stat(spec); err == nil && info.IsDir() {
+		return NewGlobSource(filepath.Join(spec, "*.log")), nil
+	}
+	return NewFileSource(spec, history), nil
+}
+
+// scanLines calls line for every line of r, a last line without newline is passed only
+// when partial is true. It returns the number of bytes consumed.
+func scanLines(r io.Reader, partial bool, line func(string)) (int64, error) {
+	var n int64
+	reader := bufio.NewReader(r)
+	for {
+		text, err := reader.ReadString('\n')
+		if err == io.EOF && (!partial || text == "") {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+		n += int64(len(text))
+
+		line(strings.TrimRight(text, "\r\n"))
+		if err == io.EOF {
+			return n, nil
+		}
+	}
+}
+
+// streamSource buffers the lines pushed by a background reader until the next Read
+type streamSource struct {
+	name string
+
+	mu    sync.Mutex
+	lines []string
+	err   error
+}
+
+func (s *streamSource) push(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.lines) >= maxBufferedLines {
+		s.lines = s.lines[1:]
+	}
+	s.lines = append(s.lines, line)
+}
+
+// fail records an error of the background reader, returned by the next Read
+func (s *streamSource) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *streamSource) Read(line func(string)) error {
+	s.mu.Lock()
+	lines, err := s.lines, s.err
+	s.lines, s.err = nil, nil
+	s.mu.Unlock()
+
+	for _, l := range lines {
+		line(l)
+	}
+	return err
+}
+
+func (s *streamSource) Name() string {
+	return s.name
+}
+
+// ReaderSource reads lines from a stream like standard input until its end
+type ReaderSource struct {
+	streamSource
+	r    io.Reader
+	done chan struct{}
+}
+
+// NewReaderSource returns a source reading r in the background
+func NewReaderSource(name string, r io.Reader) *ReaderSource {
+	s := &ReaderSource{streamSource: streamSource{name: name}, r: r, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		if _, err := scanLines(r, true, s.push); err != nil {
+			s.fail(err)
+		}
+	}()
+	return s
+}
+
+// Wait blocks until the end of the stream, so that one read gets all its lines
+func (s *ReaderSource) Wait() {
+	<-s.done
+}
+
+func (s *ReaderSource) Close() error {
+	if closer, ok := s.r.(io.Closer); ok && s.r != os.Stdin {
+		return closer.Close()
+	}
+	return nil
+}
+
+// WatchableSource is a Source reading files, whose changes can be watched instead of
+// reading the source at a fixed interval
+type WatchableSource interface {
+	Source
+	// WatchPaths returns the files read by the source
+	WatchPaths() []string
+	// Matches reports whether a change of path concerns the source, e.g. a new file
+	// matching a glob pattern or a file rotated in place of the log
+	Matches(path string) bool
+}
@@ -0,0 +1,136 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/dcvix/dcvix-stats/internal/logger"
+)
+
+// SyslogSource receives DCV log lines forwarded by a syslog daemon. The syslog header
+// is kept: the parser finds the DCV timestamp anywhere in the line.
+type SyslogSource struct {
+	streamSource
+	listener io.Closer
+}
+
+// NewSyslogSource listens for syslog messages on address, network is "udp" (one message
+// per datagram) or "tcp" (newline or octet counting framing, RFC 6587)
+func NewSyslogSource(network string, address string) (*SyslogSource, error) {
+	s := &SyslogSource{streamSource: streamSource{name: "syslog+" + network + "://" + address}}
+
+	switch network {
+	case "udp":
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return nil, err
+		}
+		s.listener = conn
+		go s.receivePackets(conn)
+	case "tcp":
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		s.listener = listener
+		go s.acceptConnections(listener)
+	default:
+		return nil, fmt.Errorf("unknown syslog network %q", network)
+	}
+
+	logger.LogVerbosef("Listening for syslog messages on %s\n", s.name)
+	return s, nil
+}
+
+func (s *SyslogSource) Close() error {
+	return s.listener.Close()
+}
+
+// maxMessageSize is the size of the largest syslog message received, the largest UDP datagram
+const maxMessageSize = 65536
+
+func (s *SyslogSource) receivePackets(conn net.PacketConn) {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !isClosed(err) {
+				s.fail(err)
+			}
+			return
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(buf[:n]), "\r\n\x00"), "\n") {
+			s.push(line)
+		}
+	}
+}
+
+func (s *SyslogSource) acceptConnections(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !isClosed(err) {
+				s.fail(err)
+			}
+			return
+		}
+		go s.receiveStream(conn)
+	}
+}
+
+// receiveStream reads the messages of a TCP connection, framed by a newline or by
+// their length followed by a space
+func (s *SyslogSource) receiveStream(conn net.Conn) {
+	defer conn.Close()
+	// Frames longer than the buffer are rejected by ReadSlice
+	reader := bufio.NewReaderSize(conn, maxMessageSize+16)
+	for {
+		first, err := reader.Peek(1)
+		if err != nil {
+			return
+		}
+
+		var message string
+		if first[0] >= '1' && first[0] <= '9' {
+			lengthStr, err := reader.ReadSlice(' ')
+			if err != nil {
+				return
+			}
+			// The length comes from the network, a large one must not be allocated
+			length, err := strconv.Atoi(strings.TrimSpace(string(lengthStr)))
+			if err != nil || length > maxMessageSize {
+				logger.LogVerbosef("Invalid syslog frame from %s\n", conn.RemoteAddr())
+				return
+			}
+			buf := make([]byte, length)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				return
+			}
+			message = string(buf)
+		} else {
+			line, err := reader.ReadSlice('\n')
+			if err == bufio.ErrBufferFull {
+				logger.LogVerbosef("Syslog message too long from %s\n", conn.RemoteAddr())
+				return
+			}
+			if err != nil && len(line) == 0 {
+				return
+			}
+			message = string(line)
+		}
+		s.push(strings.TrimRight(message, "\r\n\x00"))
+	}
+}
+
+// isClosed reports whether err is returned because the source was closed
+func isClosed(err error) bool {
+	return errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,59 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package logparser
+
+import (
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+// waitLines reads source until it returned count lines or a second elapsed
+func waitLines(t *testing.T, source Source, count int) []string {
+	t.Helper()
+	var lines []string
+	deadline := time.Now().Add(time.Second)
+	for len(lines) < count && time.Now().Before(deadline) {
+		if err := source.Read(func(line string) { lines = append(lines, line) }); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return lines
+}
+
+func TestSyslogSourceTCP(t *testing.T) {
+	source, err := NewSyslogSource("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	address := source.listener.(net.Listener).Addr().String()
+
+	// A frame length larger than a message closes the connection without allocating it
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("99999999999 <30>message"))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("connection with a large frame not closed: %v", err)
+	}
+
+	conn, err = net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("11 <30>message<30>newline framed\n"))
+
+	want := []string{"<30>message", "<30>newline framed"}
+	if lines := waitLines(t, source, len(want)); !slices.Equal(lines, want) {
+		t.Errorf("read %q, want %q", lines, want)
+	}
+}
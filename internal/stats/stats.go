@@ -0,0 +1,80 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+// Summary describes a series of values
+type Summary struct {
+	Samples int     `json:"samples"`
+	Current float64 `json:"current"`
+	Avg     float64 `json:"avg"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	P95     float64 `json:"p95"`
+}
+
+// Summarize returns the summary of values, ignoring NaN values. Current is the last value.
+func Summarize(values []float64) Summary {
+	valid := sortedValid(values)
+	if len(valid) == 0 {
+		return Summary{Current: math.NaN(), Avg: math.NaN(), Min: math.NaN(), Max: math.NaN(), P95: math.NaN()}
+	}
+
+	sum := 0.0
+	for _, v := range valid {
+		sum += v
+	}
+
+	return Summary{
+		Samples: len(valid),
+		Current: lastValid(values),
+		Avg:     sum / float64(len(valid)),
+		Min:     valid[0],
+		Max:     valid[len(valid)-1],
+		P95:     percentileSorted(valid, 95),
+	}
+}
+
+// Percentile returns the p-th percentile (0-100) of values, ignoring NaN values
+func Percentile(values []float64, p float64) float64 {
+	valid := sortedValid(values)
+	if len(valid) == 0 {
+		return math.NaN()
+	}
+	return percentileSorted(valid, p)
+}
+
+// sortedValid returns a sorted copy of values without NaN values
+func sortedValid(values []float64) []float64 {
+	var valid []float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			valid = append(valid, v)
+		}
+	}
+	slices.Sort(valid)
+	return valid
+}
+
+// percentileSorted returns the p-th percentile of sorted values, interpolating between
+// the closest ranks
+func percentileSorted(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func lastValid(values []float64) float64 {
+	for i := len(values) - 1; i >= 0; i-- {
+		if !math.IsNaN(values[i]) {
+			return values[i]
+		}
+	}
+	return math.NaN()
+}
@@ -0,0 +1,203 @@
+//  SPDX-FileCopyrightText: 2025 Diego Cortassa
+//  SPDX-License-Identifier: MIT
+
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/dcvix/dcvix-stats/internal/logger"
+	"github.com/dcvix/dcvix-stats/internal/logparser"
+)
+
+// Debounce is how long the watcher waits for more changes before notifying,
+// DCV writes its stats lines in bursts
+const Debounce = 500 * time.Millisecond
+
+// Watcher notifies when the log read by a source changes.
+// File changes are watched with fsnotify, the files are also polled at a fixed interval
+// for the filesystems not delivering events (NFS, SMB, ...). Sources not reading files,
+// like stdin or syslog, are notified at every poll.
+type Watcher struct {
+	source  logparser.Source
+	changed func()
+
+	fsWatcher *fsnotify.Watcher
+	ticker    *time.Ticker
+	done      chan struct{}
+
+	mu       sync.Mutex
+	debounce *time.Timer
+	// states are the sizes and modification times of the files at the last notification
+	states map[string]fileState
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// New starts watching the log of source, calling changed after it changes.
+// changed is called from a background goroutine.
+func New(source logparser.Source, poll time.Duration, changed func()) *Watcher {
+	w := &Watcher{
+		source:  source,
+		changed: changed,
+		ticker:  time.NewTicker(poll),
+		done:    make(chan struct{}),
+		states:  make(map[string]fileState),
+	}
+
+	if watchable, ok := source.(logparser.WatchableSource); ok {
+		w.states = w.fileStates(watchable)
+		fsWatcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			logger.LogVerbosef("Watching %s by polling only: %v\n", source.Name(), err)
+		} else {
+			w.fsWatcher = fsWatcher
+			w.watchDirs(watchable)
+			go w.receiveEvents(watchable)
+		}
+	}
+
+	go w.poll()
+	return w
+}
+
+// Close stops watching
+func (w *Watcher) Close() {
+	close(w.done)
+	w.ticker.Stop()
+	if w.fsWatcher != nil {
+		w.fsWatcher.Close()
+	}
+	w.mu.Lock()
+	if w.debounce != nil {
+		w.debounce.Stop()
+	}
+	w.mu.Unlock()
+}
+
+// watchDirs watches the directories of the files, to be notified of rotations and new files
+func (w *Watcher) watchDirs(source logparser.WatchableSource) {
+	watched := make(map[string]bool)
+	for _, dir := range w.fsWatcher.WatchList() {
+		watched[dir] = true
+	}
+	for _, path := range source.WatchPaths() {
+		dir := filepath.Dir(path)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dir = path
+		}
+		if watched[dir] {
+			continue
+		}
+		if err := w.fsWatcher.Add(dir); err != nil {
+			logger.LogVerbosef("Could not watch %s: %v\n", dir, err)
+			continue
+		}
+		watched[dir] = true
+	}
+}
+
+func (w *Watcher) receiveEvents(source logparser.WatchableSource) {
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) || !source.Matches(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				w.watchDirs(source)
+			}
+			w.notifyLater()
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			logger.LogVerbosef("Error watching %s: %v\n", source.Name(), err)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// poll notifies when the files changed without an event, or at every tick for
+// sources not reading files
+func (w *Watcher) poll() {
+	for {
+		select {
+		case <-w.ticker.C:
+			watchable, ok := w.source.(logparser.WatchableSource)
+			if !ok {
+				w.notify()
+				continue
+			}
+			states := w.fileStates(watchable)
+			w.mu.Lock()
+			changed := !sameStates(states, w.states)
+			w.mu.Unlock()
+			if changed {
+				w.notify()
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// notifyLater notifies once no more events arrive for the Debounce time
+func (w *Watcher) notifyLater() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.debounce != nil {
+		w.debounce.Stop()
+	}
+	w.debounce = time.AfterFunc(Debounce, w.notify)
+}
+
+func (w *Watcher) notify() {
+	select {
+	case <-w.done:
+		return
+	default:
+	}
+
+	if watchable, ok := w.source.(logparser.WatchableSource); ok {
+		states := w.fileStates(watchable)
+		w.mu.Lock()
+		w.states = states
+		w.mu.Unlock()
+	}
+	w.changed()
+}
+
+func (w *Watcher) fileStates(source logparser.WatchableSource) map[string]fileState {
+	states := make(map[string]fileState)
+	for _, path := range source.WatchPaths() {
+		if info, err := os.Stat(path); err == nil {
+			states[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+	return states
+}
+
+func sameStates(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		if b[path] != state {
+			return false
+		}
+	}
+	return true
+}